@@ -9,6 +9,7 @@ const (
 	Reset     = "\033[0m"
 	RGBPrefix = "\033[38;5;" // followed by RGB values in decimal format separated by colons
 	RGBSuffix = "m"
+	ClearLine = "\r\033[K" // Return to the start of the line and clear it
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -20,3 +21,14 @@ func Colorize(text string, v int) string {
 	// Grayscale colors are in the range 232-255
 	return RGBPrefix + fmt.Sprint(v%24+232) + RGBSuffix + text + Reset
 }
+
+// ColorizeProbability colors text by a probability from 0 to 1, from dark
+// gray for unlikely text to white for certain text
+func ColorizeProbability(text string, p float32) string {
+	if p < 0 {
+		p = 0
+	} else if p > 1 {
+		p = 1
+	}
+	return Colorize(text, int(p*23))
+}
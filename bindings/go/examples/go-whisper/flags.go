@@ -7,7 +7,9 @@ import (
 	"time"
 
 	// Packages
-	whisper "github.com/brave-experiments/whisper.cpp/go/pkg/whisper"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+	subtitle "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper/subtitle"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -64,14 +66,71 @@ func (flags *Flags) GetThreads() uint {
 	return flags.Lookup("threads").Value.(flag.Getter).Get().(uint)
 }
 
-func (flags *Flags) GetOut() string {
-	return strings.ToLower(flags.Lookup("out").Value.String())
+func (flags *Flags) GetStates() int {
+	return flags.Lookup("states").Value.(flag.Getter).Get().(int)
+}
+
+func (flags *Flags) GetVAD() int {
+	return flags.Lookup("vad").Value.(flag.Getter).Get().(int)
+}
+
+func (flags *Flags) GetSkipSilence() int {
+	return flags.Lookup("skip-silence").Value.(flag.Getter).Get().(int)
+}
+
+// GetOut returns the output formats, in order
+func (flags *Flags) GetOut() []string {
+	var result []string
+	for _, format := range strings.Split(flags.Lookup("out").Value.String(), ",") {
+		if format = strings.ToLower(strings.TrimSpace(format)); format != "" {
+			result = append(result, format)
+		}
+	}
+	return result
+}
+
+func (flags *Flags) GetOutDir() string {
+	return flags.Lookup("outdir").Value.String()
+}
+
+func (flags *Flags) IsSkipExisting() bool {
+	return flags.Lookup("skip-existing").Value.String() == "true"
+}
+
+func (flags *Flags) GetColumns() ([]subtitle.Column, error) {
+	return subtitle.ParseColumns(flags.Lookup("columns").Value.String())
+}
+
+func (flags *Flags) IsWords() bool {
+	return flags.Lookup("words").Value.String() == "true"
+}
+
+// GetFormat returns the format of raw input streams, or a format with
+// ENCODING_NONE when the -format flag is not set
+func (flags *Flags) GetFormat() (audio.Format, error) {
+	if format := flags.Lookup("format").Value.String(); format == "" {
+		return audio.Format{}, nil
+	} else {
+		return audio.ParseFormat(format)
+	}
+}
+
+func (flags *Flags) GetChunk() time.Duration {
+	return flags.Lookup("chunk").Value.(flag.Getter).Get().(time.Duration)
 }
 
 func (flags *Flags) IsSpeedup() bool {
 	return flags.Lookup("speedup").Value.String() == "true"
 }
 
+func (flags *Flags) IsTinydiarize() bool {
+	return flags.Lookup("tdrz").Value.String() == "true"
+}
+
+func (flags *Flags) IsLive() bool {
+	return flags.Lookup("live").Value.String() == "true"
+}
+
 func (flags *Flags) IsTokens() bool {
 	return flags.Lookup("tokens").Value.String() == "true"
 }
@@ -111,6 +170,14 @@ func (flags *Flags) SetParams(context whisper.Context) error {
 		fmt.Fprintf(flags.Output(), "Setting duration to %v\n", duration)
 		context.SetDuration(duration)
 	}
+	if flags.IsTinydiarize() {
+		fmt.Fprintf(flags.Output(), "Setting tinydiarize to true\n")
+		context.SetTinydiarize(true)
+	}
+	if flags.IsWords() {
+		fmt.Fprintf(flags.Output(), "Setting token_timestamps to true\n")
+		context.SetTokenTimestamps(true)
+	}
 	if flags.IsSpeedup() {
 		fmt.Fprintf(flags.Output(), "Setting speedup to true\n")
 		context.SetSpeedup(true)
@@ -131,6 +198,14 @@ func (flags *Flags) SetParams(context whisper.Context) error {
 		fmt.Fprintf(flags.Output(), "Setting word_threshold to %f\n", word_threshold)
 		context.SetTokenThreshold(word_threshold)
 	}
+	if vad := flags.GetVAD(); vad >= 0 {
+		fmt.Fprintf(flags.Output(), "Setting vad aggressiveness to %d\n", vad)
+		context.SetVAD(audio.NewVAD(vad))
+	}
+	if gate := flags.GetSkipSilence(); gate >= 0 {
+		fmt.Fprintf(flags.Output(), "Setting skip_silence aggressiveness to %d\n", gate)
+		context.SetSilenceGate(audio.NewVAD(gate))
+	}
 
 	// Return success
 	return nil
@@ -150,8 +225,18 @@ func registerFlags(flag *Flags) {
 	flag.Uint("max-len", 0, "Maximum segment length in characters")
 	flag.Uint("max-tokens", 0, "Maximum tokens per segment")
 	flag.Float64("word-thold", 0, "Maximum segment score")
-	flag.Bool("tokens", false, "Display tokens")
-	flag.Bool("colorize", false, "Colorize tokens")
-	flag.String("out", "", "Output format (srt, none or leave as empty string)")
+	flag.Bool("tokens", false, "Display tokens with their probabilities")
+	flag.Bool("colorize", false, "Colorize tokens by probability")
+	flag.Bool("live", false, "Live captions from stdin, or from a WAV file which is being written")
+	flag.String("format", "", "Format of raw input streams as encoding[:rate[:channels]] (ulaw, alaw, s16le, f32le)")
+	flag.Duration("chunk", 10*time.Second, "Duration of audio in each processing window when reading from stdin")
+	flag.String("out", "", "Comma separated output formats (srt, vtt, ass, scc, ttml, csv, tsv, lrc, html, json, txt, none or leave as empty string)")
+	flag.String("outdir", "", "Directory for output files named after each input, which are written next to the input when there is more than one format")
+	flag.Bool("skip-existing", false, "Skip inputs when all output files exist, with -outdir or more than one -out format")
+	flag.String("columns", "start,end,text", "Columns of csv and tsv output (start, end, text, confidence, speaker)")
+	flag.Bool("words", false, "Word timestamps, for enhanced lrc and karaoke vtt, ttml and ass output")
+	flag.Bool("tdrz", false, "Detect speaker turns, which needs a tinydiarize model")
 	flag.Int("states", 1, "Number of parallel states")
+	flag.Int("vad", -1, "Only process speech, with voice activity detection aggressiveness from 0 to 3 (-1 = disabled)")
+	flag.Int("skip-silence", -1, "Skip encoder windows without speech, with voice activity detection aggressiveness from 0 to 3 (-1 = disabled)")
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Package imports
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// followReader reads a file which is still being written, waiting for more
+// data at the end of the file until it stops growing
+type followReader struct {
+	r    io.Reader
+	idle time.Duration
+}
+
+// liveWriter draws final lines and the partial hypothesis below them in a
+// terminal
+type liveWriter struct {
+	w     io.Writer
+	width int
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	livePoll  = 100 * time.Millisecond // Time between reads at the end of a file
+	liveIdle  = 5 * time.Second        // Time without growth which ends a file
+	liveBlock = audio.SampleRate / 10  // Samples pushed at a time
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ProcessLive transcribes stdin, or a WAV file which is being written, as
+// live captions. The partial hypothesis is redrawn in place as audio
+// arrives, and lines are printed once final, with each token colored by
+// its probability.
+func ProcessLive(model whisper.Model, path string, flags *Flags) error {
+	// Create processing context
+	context, err := model.NewContext()
+	if err != nil {
+		return err
+	}
+
+	// Set the parameters
+	if err := flags.SetParams(context); err != nil {
+		return err
+	}
+
+	// Open the stream. Files are read from the start of their samples, and
+	// followed as they grow.
+	format, err := flags.GetFormat()
+	if err != nil {
+		return err
+	}
+	var r io.Reader
+	if path == "-" {
+		if format.Encoding == audio.ENCODING_NONE {
+			return errors.New("use -format flag to describe the input stream")
+		}
+		r = os.Stdin
+	} else {
+		fh, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		r = &followReader{fh, liveIdle}
+		if format.Encoding == audio.ENCODING_NONE {
+			var size int64
+			if format, size, err = audio.ReadWAVHeader(r); err != nil {
+				return err
+			} else if size >= 0 {
+				r = io.LimitReader(r, size)
+			}
+		}
+	}
+	reader, err := audio.NewReader(r, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(flags.Output(), "Live captions from %v stream\n", format)
+
+	streamer := context.NewStreamer()
+	defer streamer.Close()
+
+	// Push audio as it arrives, and redraw after each step
+	live := &liveWriter{os.Stdout, terminalWidth()}
+	buf := make([]float32, liveBlock)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			final, partial, err := streamer.Push(buf[:n])
+			if err != nil {
+				return err
+			}
+			if final != nil || partial != nil {
+				if err := live.Draw(final, partial); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Commit the rest of the stream
+	final, err := streamer.Flush()
+	if err != nil {
+		return err
+	}
+	return live.Draw(final, nil)
+}
+
+// Read waits at the end of the file for more data, and returns io.EOF once
+// the file has not grown for the idle time
+func (r *followReader) Read(data []byte) (int, error) {
+	deadline := time.Now().Add(r.idle)
+	for {
+		n, err := r.r.Read(data)
+		if n > 0 || err != io.EOF {
+			return n, err
+		} else if time.Now().After(deadline) {
+			return 0, io.EOF
+		}
+		time.Sleep(livePoll)
+	}
+}
+
+// Draw prints final segments as lines, replacing the partial hypothesis,
+// and then the new partial hypothesis without a newline
+func (l *liveWriter) Draw(final, partial []whisper.Segment) error {
+	var b strings.Builder
+	b.WriteString(ClearLine)
+	for _, segment := range final {
+		if text := colorizeTokens(segment); text != "" {
+			fmt.Fprintf(&b, "[%8s] %s\n", segment.Start.Round(time.Second/10), text)
+		}
+	}
+	b.WriteString(l.partialLine(partial))
+	_, err := io.WriteString(l.w, b.String())
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// partialLine returns the end of the partial hypothesis which fits on one
+// line of the terminal, with each token colored by its probability
+func (l *liveWriter) partialLine(partial []whisper.Segment) string {
+	var tokens []whisper.Token
+	for _, segment := range partial {
+		for _, token := range segment.Tokens {
+			if !token.IsSpecial() {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	// Keep the last tokens which fit after the prompt
+	const prompt = "> "
+	i, width := len(tokens), utf8.RuneCountInString(prompt)
+	for i > 0 {
+		n := utf8.RuneCountInString(tokens[i-1].Text)
+		if width+n >= l.width {
+			break
+		}
+		i, width = i-1, width+n
+	}
+	if i == len(tokens) {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	for j, token := range tokens[i:] {
+		text := token.Text
+		if j == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		b.WriteString(ColorizeProbability(text, token.P))
+	}
+	return b.String()
+}
+
+// terminalWidth returns the width of the terminal from the COLUMNS
+// environment variable, or 80
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
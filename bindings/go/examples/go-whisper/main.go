@@ -7,7 +7,7 @@ import (
 	"path/filepath"
 
 	// Packages
-	whisper "github.com/brave-experiments/whisper.cpp/go/pkg/whisper"
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
 )
 
 func main() {
@@ -25,6 +25,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Check the output files before loading the model
+	if !flags.IsLive() {
+		if err := CheckOutputs(flags.Args(), flags); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	// Load model
 	model, err := whisper.New(flags.GetModel())
 	if err != nil {
@@ -35,7 +43,18 @@ func main() {
 
 	// Process files
 	for _, filename := range flags.Args() {
-		if err := Process(model, filename, flags); err != nil {
+		if flags.IsSkipExisting() && !flags.IsLive() && OutputsExist(filename, flags) {
+			fmt.Fprintf(flags.Output(), "Skipping %q, outputs exist\n", filename)
+			continue
+		}
+		if flags.IsLive() {
+			err = ProcessLive(model, filename, flags)
+		} else if filename == "-" {
+			err = ProcessStream(model, os.Stdin, flags)
+		} else {
+			err = Process(model, filename, flags)
+		}
+		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			continue
 		}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Package imports
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+	subtitle "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper/subtitle"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Outputs writes segments in each format of the -out flag. With one format
+// and no -outdir, segments are written to stdout. Otherwise each format is
+// written to a sidecar file named after the input, through a temporary
+// file which is renamed when the outputs are closed.
+type Outputs struct {
+	encoders []subtitle.Encoder
+	files    []*sidecar
+}
+
+// sidecar is a temporary file which replaces the file at path on commit
+type sidecar struct {
+	*os.File
+	path string
+	done bool
+}
+
+// textEncoder prints each segment with its timestamps, and optionally its
+// tokens
+type textEncoder struct {
+	w        io.Writer
+	tokens   bool
+	colorize bool
+}
+
+// plainEncoder writes the text of each segment on a line
+type plainEncoder struct {
+	w io.Writer
+}
+
+// transcriptEncoder writes the segments as a JSON transcript when closed
+type transcriptEncoder struct {
+	w        io.Writer
+	context  whisper.Context
+	segments []whisper.Segment
+}
+
+// speakerEncoder is an encoder which labels speakers, following speaker
+// turns
+type speakerEncoder interface {
+	SetSpeakers(labels ...string)
+}
+
+// karaokeEncoder is an encoder which highlights each word as it is spoken
+type karaokeEncoder interface {
+	SetKaraoke(v bool)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewOutputs returns the outputs for an input path, where "-" is stdin
+func NewOutputs(path string, flags *Flags, context whisper.Context) (*Outputs, error) {
+	outputs := new(Outputs)
+	paths := OutputPaths(path, flags)
+	if paths == nil {
+		var format string
+		if formats := outputFormats(flags); len(formats) > 0 {
+			format = formats[0]
+		}
+		encoder, err := NewOutput(os.Stdout, format, flags, context)
+		if err != nil {
+			return nil, err
+		}
+		outputs.encoders = append(outputs.encoders, encoder)
+		return outputs, nil
+	}
+
+	// Create a temporary file for each format
+	if dir := flags.GetOutDir(); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	for i, format := range outputFormats(flags) {
+		file, err := newSidecar(paths[i])
+		if err != nil {
+			outputs.Abort()
+			return nil, err
+		}
+		outputs.files = append(outputs.files, file)
+		encoder, err := NewOutput(file, format, flags, context)
+		if err != nil {
+			outputs.Abort()
+			return nil, err
+		}
+		outputs.encoders = append(outputs.encoders, encoder)
+	}
+
+	// Return success
+	return outputs, nil
+}
+
+// NewOutput returns an encoder for a format, which writes to w. Without a
+// format, segments are printed with timestamps.
+func NewOutput(w io.Writer, format string, flags *Flags, context whisper.Context) (subtitle.Encoder, error) {
+	switch format {
+	case "", "none":
+		return &textEncoder{w, flags.IsTokens(), flags.IsColorize()}, nil
+	case "txt":
+		return &plainEncoder{w}, nil
+	case "json":
+		return &transcriptEncoder{w: w, context: context}, nil
+	default:
+		encoder, err := subtitle.NewEncoder(format, w)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", err, format)
+		}
+
+		// Label speakers when detecting speaker turns, and set options for
+		// each format
+		if speakers, ok := encoder.(speakerEncoder); ok && flags.IsTinydiarize() {
+			speakers.SetSpeakers("Speaker 1", "Speaker 2")
+		}
+		if karaoke, ok := encoder.(karaokeEncoder); ok {
+			karaoke.SetKaraoke(flags.IsWords())
+		}
+		switch encoder := encoder.(type) {
+		case *subtitle.TTMLEncoder:
+			if lang := flags.GetLanguage(); lang != "auto" {
+				encoder.SetLanguage(lang)
+			}
+		case *subtitle.CSVEncoder:
+			columns, err := flags.GetColumns()
+			if err != nil {
+				return nil, err
+			}
+			encoder.SetColumns(columns...)
+		case *subtitle.LRCEncoder:
+			encoder.SetEnhanced(flags.IsWords())
+		}
+		return encoder, nil
+	}
+}
+
+// OutputPaths returns the sidecar files for an input path, in the order of
+// the -out formats, or nil when writing to stdout. Sidecar files are
+// written to -outdir, or next to the input when there is more than one
+// format.
+func OutputPaths(path string, flags *Flags) []string {
+	formats, dir := outputFormats(flags), flags.GetOutDir()
+	if len(formats) == 0 || (len(formats) == 1 && dir == "") {
+		return nil
+	}
+
+	// Name the files after the input, without its extension
+	name := "stdin"
+	if path != "-" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if dir == "" {
+			dir = filepath.Dir(path)
+		}
+	}
+	result := make([]string, len(formats))
+	for i, format := range formats {
+		result[i] = filepath.Join(dir, name+"."+outputExt(format))
+	}
+	return result
+}
+
+// CheckOutputs returns an error when -skip-existing is set without sidecar
+// files, or when two inputs would write the same sidecar file
+func CheckOutputs(inputs []string, flags *Flags) error {
+	paths := make(map[string]string)
+	for _, input := range inputs {
+		outputs := OutputPaths(input, flags)
+		if outputs == nil && flags.IsSkipExisting() {
+			return errors.New("-skip-existing needs -outdir, or more than one -out format")
+		}
+		for _, path := range outputs {
+			if other, exists := paths[filepath.Clean(path)]; exists {
+				return fmt.Errorf("%q and %q both write %q", other, input, path)
+			}
+			paths[filepath.Clean(path)] = input
+		}
+	}
+	return nil
+}
+
+// OutputsExist returns true when writing to sidecar files which all exist
+func OutputsExist(path string, flags *Flags) bool {
+	paths := OutputPaths(path, flags)
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return len(paths) > 0
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Encode writes segments to each output
+func (o *Outputs) Encode(segments []whisper.Segment) error {
+	for _, encoder := range o.encoders {
+		if err := encoder.Encode(segments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetReport sets the title and audio of html reports
+func (o *Outputs) SetReport(title string, data []float32) {
+	for _, encoder := range o.encoders {
+		if report, ok := encoder.(*subtitle.HTMLEncoder); ok {
+			report.SetTitle(title)
+			report.SetAudio(data)
+		}
+	}
+}
+
+// Close each encoder, then replace the sidecar files with their temporary
+// files. On error, the temporary files are removed.
+func (o *Outputs) Close() error {
+	defer o.Abort()
+	for _, encoder := range o.encoders {
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+	}
+	for _, file := range o.files {
+		if err := file.Commit(); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Abort removes the temporary files which have not been committed, leaving
+// any existing sidecar files as they are
+func (o *Outputs) Abort() {
+	for _, file := range o.files {
+		file.Abort()
+	}
+}
+
+func (e *textEncoder) Encode(segments []whisper.Segment) error {
+	for _, segment := range segments {
+		text := segment.Text
+		if e.colorize {
+			text = colorizeTokens(segment)
+		}
+		if _, err := fmt.Fprintf(e.w, "[%6s->%6s] %s\n", segment.Start, segment.End, text); err != nil {
+			return err
+		}
+		if !e.tokens {
+			continue
+		}
+		for _, token := range segment.Tokens {
+			if token.IsSpecial() {
+				continue
+			}
+			if _, err := fmt.Fprintf(e.w, "  [%6s->%6s] %-16q p=%.2f\n", token.Start, token.End, token.Text, token.P); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *textEncoder) Close() error {
+	return nil
+}
+
+func (e *plainEncoder) Encode(segments []whisper.Segment) error {
+	for _, segment := range segments {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			if _, err := fmt.Fprintln(e.w, text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *plainEncoder) Close() error {
+	return nil
+}
+
+func (e *transcriptEncoder) Encode(segments []whisper.Segment) error {
+	e.segments = append(e.segments, segments...)
+	return nil
+}
+
+func (e *transcriptEncoder) Close() error {
+	transcript, err := whisper.NewTranscript(e.context, e.segments)
+	if err != nil {
+		return err
+	}
+	return whisper.EncodeTranscript(e.w, transcript)
+}
+
+// Commit closes the temporary file and renames it to the path of the
+// sidecar file
+func (f *sidecar) Commit() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort closes and removes the temporary file, unless it has been committed
+func (f *sidecar) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// newSidecar returns a temporary file in the same directory as path, so
+// that it can be renamed over path
+func newSidecar(path string) (*sidecar, error) {
+	fh, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := fh.Chmod(0644); err != nil {
+		fh.Close()
+		os.Remove(fh.Name())
+		return nil, err
+	}
+	return &sidecar{File: fh, path: path}, nil
+}
+
+// outputFormats returns the formats written to sidecar files, without
+// "none"
+func outputFormats(flags *Flags) []string {
+	var result []string
+	for _, format := range flags.GetOut() {
+		if format != "none" {
+			result = append(result, format)
+		}
+	}
+	return result
+}
+
+// outputExt returns the file extension for a format
+func outputExt(format string) string {
+	switch format {
+	case "webvtt":
+		return "vtt"
+	default:
+		return format
+	}
+}
+
+// colorizeTokens returns the text tokens of a segment, each colored by its
+// probability, or the text of the segment when there are no tokens
+func colorizeTokens(segment whisper.Segment) string {
+	var b strings.Builder
+	for _, token := range segment.Tokens {
+		if text := token.Text; !token.IsSpecial() {
+			if b.Len() == 0 {
+				text = strings.TrimLeft(text, " ")
+			}
+			b.WriteString(ColorizeProbability(text, token.P))
+		}
+	}
+	if b.Len() == 0 {
+		return segment.Text
+	}
+	return b.String()
+}
+
+// shiftSegments moves the timestamps of segments and their tokens by offset
+func shiftSegments(segments []whisper.Segment, offset time.Duration) {
+	for i := range segments {
+		segments[i].Start += offset
+		segments[i].End += offset
+		tokens := make([]whisper.Token, len(segments[i].Tokens))
+		for j, token := range segments[i].Tokens {
+			token.Start += offset
+			token.End += offset
+			tokens[j] = token
+		}
+		segments[i].Tokens = tokens
+	}
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
-	"flag"
 
 	// Package imports
-	whisper "github.com/brave-experiments/whisper.cpp/go/pkg/whisper"
-	wav "github.com/go-audio/wav"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
 )
 
 func Process(model whisper.Model, path string, flags *Flags) error {
@@ -25,7 +27,7 @@ func Process(model whisper.Model, path string, flags *Flags) error {
 		return err
 	}
 
-	fmt.Printf("\n%s\n", context.SystemInfo())
+	fmt.Fprintf(flags.Output(), "\n%s\n", context.SystemInfo())
 
 	// Open the file
 	fmt.Fprintf(flags.Output(), "Loading %q\n", path)
@@ -35,52 +37,125 @@ func Process(model whisper.Model, path string, flags *Flags) error {
 	}
 	defer fh.Close()
 
-	// Decode the WAV file - load the full buffer
-	dec := wav.NewDecoder(fh)
-	if buf, err := dec.FullPCMBuffer(); err != nil {
+	// Raw streams are described by the -format flag or the file extension,
+	// anything else is decoded as a WAV file
+	format, err := flags.GetFormat()
+	if err != nil {
 		return err
-	} else if dec.SampleRate != whisper.SampleRate {
-		return fmt.Errorf("unsupported sample rate: %d", dec.SampleRate)
-	} else if dec.NumChans != 1 {
-		return fmt.Errorf("unsupported number of channels: %d", dec.NumChans)
+	} else if format.Encoding == audio.ENCODING_NONE {
+		format, _ = audio.FormatForPath(path)
+	}
+
+	// Decode the file - load the full buffer
+	if format.Encoding != audio.ENCODING_NONE {
+		fmt.Fprintf(flags.Output(), "Decoding raw %v stream\n", format)
+		data, err = audio.DecodeRaw(fh, format)
 	} else {
-		data = buf.AsFloat32Buffer().Data
+		data, err = audio.DecodeWAV(fh)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Segment callback when -tokens is specified
+	// Process the data, splitting it between states when -states is
+	// more than one
+	var segments []whisper.Segment
+	context.ResetTimings()
+	if n := flags.GetStates(); n > 1 {
+		fmt.Fprintf(flags.Output(), "Processing with %d parallel states\n", n)
+		segments, err = whisper.TranscribeParallel(context, data, whisper.ParallelOptions{States: n})
+	} else {
+		state := context.NewState()
+		defer state.Close()
+		segments, err = context.Process(state, data)
+	}
+	if err != nil {
+		return err
+	}
+	context.PrintTimings()
 
-	// Process the data
+	// Write out the results, with the audio for reports
+	out, err := NewOutputs(path, flags, context)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+	out.SetReport(filepath.Base(path), data)
+	if err := out.Encode(segments); err != nil {
+		return err
+	}
 
-	n := flags.Lookup("states").Value.(flag.Getter).Get().(int)
-	quit:= make(chan string, n)
+	// Return success
+	return out.Close()
+}
 
-	context.ResetTimings()
-	for i := 0; i < n; i++ {
-		//go func() error {
-			state := context.NewState()
-			quit <- fmt.Sprintf("%p %p", state, data)
+// ProcessStream transcribes a raw audio stream as it arrives. The stream is
+// cut into windows of the -chunk duration, and each window is processed as
+// soon as it has been read, with timestamps relative to the stream start.
+func ProcessStream(model whisper.Model, r io.Reader, flags *Flags) error {
+	// Create processing context
+	context, err := model.NewContext()
+	if err != nil {
+		return err
+	}
 
-			/*if segments, err := context.Process(state, data); err != nil {
-				quit <- "error"
-				return err
-			} else {
-				result := fmt.Sprintf("%p", state)
-				for _, segment := range segments {
-					result += " " + segment.Text
-				}
-				quit <- result
-			}*/
-		//	return nil
-	        //}()
+	// Set the parameters
+	if err := flags.SetParams(context); err != nil {
+		return err
 	}
 
-	for i := 0; i < n; i++ {
-		fmt.Println(<-quit)
+	// Create the stream reader
+	format, err := flags.GetFormat()
+	if err != nil {
+		return err
+	} else if format.Encoding == audio.ENCODING_NONE {
+		return errors.New("use -format flag to describe the input stream")
 	}
+	reader, err := audio.NewReader(r, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(flags.Output(), "Reading raw %v stream\n", format)
 
-	context.PrintTimings()
+	state := context.NewState()
+	defer state.Close()
+
+	out, err := NewOutputs("-", flags, context)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
 
-	time.Sleep(10 * time.Second)
+	// Process each window as it fills up, until the end of the stream
+	window := make([]float32, int(flags.GetChunk().Seconds()*audio.SampleRate))
+	if len(window) == 0 {
+		return errors.New("-chunk must be at least one sample long")
+	}
+	var offset time.Duration
+	for err == nil {
+		n := 0
+		for n < len(window) && err == nil {
+			var m int
+			m, err = reader.Read(window[n:])
+			n += m
+		}
+		if n == 0 {
+			break
+		}
+		segments, perr := context.Process(state, window[:n])
+		if perr != nil {
+			return perr
+		}
+		shiftSegments(segments, offset)
+		if err := out.Encode(segments); err != nil {
+			return err
+		}
+		offset += time.Duration(n) * time.Second / audio.SampleRate
+	}
+	if err != io.EOF {
+		return err
+	}
 
-	return nil
+	// Return success
+	return out.Close()
 }
@@ -19,6 +19,11 @@ func (p *Params) SetTranslate(v bool) {
 	p.translate = toBool(v)
 }
 
+// Get translate flag
+func (p *Params) Translate() bool {
+	return bool(p.translate)
+}
+
 func (p *Params) SetSplitOnWord(v bool) {
 	p.split_on_word = toBool(v)
 }
@@ -89,11 +94,21 @@ func (p *Params) SetOffset(offset_ms int) {
 	p.offset_ms = C.int(offset_ms)
 }
 
+// Get start offset in ms
+func (p *Params) Offset() int {
+	return int(p.offset_ms)
+}
+
 // Set audio duration to process in ms
 func (p *Params) SetDuration(duration_ms int) {
 	p.duration_ms = C.int(duration_ms)
 }
 
+// Get audio duration to process in ms (0 = all audio)
+func (p *Params) Duration() int {
+	return int(p.duration_ms)
+}
+
 // Set timestamp token probability threshold (~0.01)
 func (p *Params) SetTokenThreshold(t float32) {
 	p.thold_pt = C.float(t)
@@ -122,6 +137,46 @@ func (p *Params) SetSuppressNonSpeechTokens(b bool) {
 	p.suppress_non_speech_tokens = toBool(b)
 }
 
+// Set tinydiarize speaker turn detection, which needs a tinydiarize model
+func (p *Params) SetTinydiarize(v bool) {
+	p.tdrz_enable = toBool(v)
+}
+
+// Get tinydiarize flag
+func (p *Params) Tinydiarize() bool {
+	return bool(p.tdrz_enable)
+}
+
+// Set initial decoding temperature
+func (p *Params) SetTemperature(t float32) {
+	p.temperature = C.float(t)
+}
+
+// Set the sampling strategy
+func (p *Params) SetStrategy(strategy SamplingStrategy) {
+	p.strategy = C.enum_whisper_sampling_strategy(strategy)
+}
+
+// Set number of candidates when sampling greedily with a non-zero temperature
+func (p *Params) SetBestOf(n int) {
+	p.greedy.best_of = C.int(n)
+}
+
+// Set number of beams for beam search
+func (p *Params) SetBeamSize(n int) {
+	p.beam_search.beam_size = C.int(n)
+}
+
+// Set temperature increase when decoding fails and is retried (0 = no fallback)
+func (p *Params) SetTemperatureFallback(t float32) {
+	p.temperature_inc = C.float(t)
+}
+
+// Set audio context size (0 = use default)
+func (p *Params) SetAudioCtx(n int) {
+	p.audio_ctx = C.int(n)
+}
+
 
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
@@ -173,6 +228,12 @@ func (p *Params) String() string {
 	if p.speed_up {
 		str += " speed_up"
 	}
+	if p.tdrz_enable {
+		str += " tdrz_enable"
+	}
+	if p.audio_ctx != 0 {
+		str += fmt.Sprintf(" audio_ctx=%d", p.audio_ctx)
+	}
 
 	return str + ">"
 }
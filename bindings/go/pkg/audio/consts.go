@@ -0,0 +1,36 @@
+package audio
+
+import (
+	"errors"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// ERRORS
+
+var (
+	ErrInvalidFormat       = errors.New("invalid format")
+	ErrUnsupportedEncoding = errors.New("unsupported encoding")
+	ErrUnsupportedRate     = errors.New("unsupported sample rate")
+	ErrUnsupportedChannels = errors.New("unsupported number of channels")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// SampleRate is the sample rate of the decoded audio data.
+const SampleRate = 16000
+
+// TelephonyRate is the sample rate of G.711 audio data.
+const TelephonyRate = 8000
+
+// WAV format tags
+const (
+	WAV_FORMAT_PCM        = 1
+	WAV_FORMAT_FLOAT      = 3
+	WAV_FORMAT_ALAW       = 6
+	WAV_FORMAT_ULAW       = 7
+	WAV_FORMAT_EXTENSIBLE = 0xFFFE
+)
+
+// Largest WAV format chunk, which is 40 bytes for WAVE_FORMAT_EXTENSIBLE
+const wavMaxFormatSize = 64
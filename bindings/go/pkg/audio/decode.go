@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	// Package imports
+	wav "github.com/go-audio/wav"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DecodeWAV reads a WAV file and returns 16 kHz mono samples. Linear PCM at
+// any sample rate and G.711 mu-law or A-law (format tags 7 and 6) are
+// supported. Multi-channel audio is mixed down to mono.
+func DecodeWAV(r io.ReadSeeker) ([]float32, error) {
+	dec := wav.NewDecoder(r)
+	if err := dec.FwdToPCM(); err != nil {
+		return nil, err
+	} else if dec.PCMChunk == nil {
+		return nil, wav.ErrPCMChunkNotFound
+	}
+
+	switch dec.WavAudioFormat {
+	case WAV_FORMAT_PCM:
+		if buf, err := dec.FullPCMBuffer(); err != nil {
+			return nil, err
+		} else {
+			return Resample(Downmix(buf.AsFloat32Buffer().Data, int(dec.NumChans)), int(dec.SampleRate))
+		}
+	case WAV_FORMAT_ULAW:
+		return DecodeRaw(dec.PCMChunk, Format{ENCODING_ULAW, int(dec.SampleRate), int(dec.NumChans)})
+	case WAV_FORMAT_ALAW:
+		return DecodeRaw(dec.PCMChunk, Format{ENCODING_ALAW, int(dec.SampleRate), int(dec.NumChans)})
+	default:
+		return nil, fmt.Errorf("%w: wav format tag %d", ErrUnsupportedEncoding, dec.WavAudioFormat)
+	}
+}
+
+// DecodeRaw reads a headerless audio stream in the given format until EOF
+// and returns 16 kHz mono samples.
+func DecodeRaw(r io.Reader, format Format) ([]float32, error) {
+	if format.Channels < 1 {
+		return nil, ErrUnsupportedChannels
+	} else if format.BytesPerFrame() == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEncoding, format.Encoding)
+	}
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	buf = buf[:len(buf)-len(buf)%format.BytesPerFrame()]
+
+	// Convert to mono and resample
+	return Resample(Downmix(decodeSamples(buf, format.Encoding), format.Channels), format.SampleRate)
+}
+
+// ReadWAVHeader reads the header of a WAV file up to the start of the
+// samples, and returns the format of the samples, which can then be read
+// as a raw stream as they arrive. The file does not need to be complete.
+// 16-bit linear PCM, 32-bit float and G.711 samples are supported.
+//
+// The size of the samples in bytes is also returned, so that chunks after
+// the samples are not read as audio. It is -1 when the size is not known,
+// as in the header of a file which is still being written.
+func ReadWAVHeader(r io.Reader) (Format, int64, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return Format{}, 0, err
+	} else if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return Format{}, 0, ErrInvalidFormat
+	}
+
+	// Read chunks until the data chunk
+	var format Format
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return Format{}, 0, err
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if size < 16 || size > wavMaxFormatSize {
+				return Format{}, 0, ErrInvalidFormat
+			}
+			buf := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return Format{}, 0, err
+			}
+			tag := binary.LittleEndian.Uint16(buf[0:])
+			if tag == WAV_FORMAT_EXTENSIBLE && size >= 26 {
+				tag = binary.LittleEndian.Uint16(buf[24:])
+			}
+			format.Channels = int(binary.LittleEndian.Uint16(buf[2:]))
+			format.SampleRate = int(binary.LittleEndian.Uint32(buf[4:]))
+			switch bits := binary.LittleEndian.Uint16(buf[14:]); {
+			case tag == WAV_FORMAT_PCM && bits == 16:
+				format.Encoding = ENCODING_S16LE
+			case tag == WAV_FORMAT_FLOAT && bits == 32:
+				format.Encoding = ENCODING_F32LE
+			case tag == WAV_FORMAT_ULAW && bits == 8:
+				format.Encoding = ENCODING_ULAW
+			case tag == WAV_FORMAT_ALAW && bits == 8:
+				format.Encoding = ENCODING_ALAW
+			default:
+				return Format{}, 0, fmt.Errorf("%w: wav format tag %d with %d bits", ErrUnsupportedEncoding, tag, bits)
+			}
+		case "data":
+			if format.Encoding == ENCODING_NONE {
+				return Format{}, 0, ErrInvalidFormat
+			}
+			if size == 0 || size == math.MaxUint32 {
+				size = -1
+			}
+			return format, size, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return Format{}, 0, err
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// decodeSamples converts whole samples of encoded data into float32 samples
+func decodeSamples(buf []byte, encoding Encoding) []float32 {
+	switch encoding {
+	case ENCODING_ULAW:
+		return DecodeULaw(buf)
+	case ENCODING_ALAW:
+		return DecodeALaw(buf)
+	case ENCODING_S16LE:
+		result := make([]float32, len(buf)/2)
+		for i := range result {
+			result[i] = float32(int16(binary.LittleEndian.Uint16(buf[i*2:]))) / 32768
+		}
+		return result
+	case ENCODING_F32LE:
+		result := make([]float32, len(buf)/4)
+		for i := range result {
+			result[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+		}
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadWAVHeader(t *testing.T) {
+	pcm := wavFormatChunk(16, WAV_FORMAT_PCM, 1, 16000, 16)
+	tests := []struct {
+		name   string
+		data   []byte
+		format Format
+		size   int64
+		err    error
+	}{
+		{"pcm", wavFile(pcm, wavChunk("data", 4, nil)), Format{ENCODING_S16LE, 16000, 1}, 4, nil},
+		{"ulaw", wavFile(wavFormatChunk(16, WAV_FORMAT_ULAW, 1, 8000, 8), wavChunk("data", 8, nil)), Format{ENCODING_ULAW, 8000, 1}, 8, nil},
+		{"alaw", wavFile(wavFormatChunk(18, WAV_FORMAT_ALAW, 2, 8000, 8), wavChunk("data", 8, nil)), Format{ENCODING_ALAW, 8000, 2}, 8, nil},
+		{"float", wavFile(wavFormatChunk(16, WAV_FORMAT_FLOAT, 1, 44100, 32), wavChunk("data", 8, nil)), Format{ENCODING_F32LE, 44100, 1}, 8, nil},
+		{"extensible", wavFile(wavExtensibleChunk(WAV_FORMAT_PCM, 16), wavChunk("data", 4, nil)), Format{ENCODING_S16LE, 48000, 2}, 4, nil},
+		{"odd chunk skipped", wavFile(wavChunk("LIST", 3, []byte("abc")), pcm, wavChunk("data", 4, nil)), Format{ENCODING_S16LE, 16000, 1}, 4, nil},
+		{"streaming size zero", wavFile(pcm, wavChunk("data", 0, nil)), Format{ENCODING_S16LE, 16000, 1}, -1, nil},
+		{"streaming size max", wavFile(pcm, wavChunk("data", 0xFFFFFFFF, nil)), Format{ENCODING_S16LE, 16000, 1}, -1, nil},
+		{"empty", nil, Format{}, 0, io.EOF},
+		{"truncated riff", []byte("RIFF\x00\x00"), Format{}, 0, io.ErrUnexpectedEOF},
+		{"not wave", []byte("RIFF\x00\x00\x00\x00AVI "), Format{}, 0, ErrInvalidFormat},
+		{"truncated chunk header", wavFile([]byte("fmt \x10")), Format{}, 0, io.ErrUnexpectedEOF},
+		{"truncated fmt", wavFile(pcm[:20]), Format{}, 0, io.ErrUnexpectedEOF},
+		{"truncated skipped chunk", wavFile(wavChunk("LIST", 100, []byte("abc"))), Format{}, 0, io.EOF},
+		{"small fmt", wavFile(wavChunk("fmt ", 14, make([]byte, 14)), wavChunk("data", 4, nil)), Format{}, 0, ErrInvalidFormat},
+		{"oversized fmt", wavFile(wavChunk("fmt ", 1<<30, nil)), Format{}, 0, ErrInvalidFormat},
+		{"data before fmt", wavFile(wavChunk("data", 4, nil), pcm), Format{}, 0, ErrInvalidFormat},
+		{"unsupported", wavFile(wavFormatChunk(16, WAV_FORMAT_PCM, 1, 16000, 24), wavChunk("data", 4, nil)), Format{}, 0, ErrUnsupportedEncoding},
+	}
+	for _, test := range tests {
+		format, size, err := ReadWAVHeader(bytes.NewReader(test.data))
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if format != test.format || size != test.size {
+			t.Errorf("%s: got %v with size %d, expected %v with size %d", test.name, format, size, test.format, test.size)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// wavFile returns a RIFF header followed by the chunks
+func wavFile(chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	data := []byte("RIFF\x00\x00\x00\x00WAVE")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)+4))
+	return append(data, body...)
+}
+
+// wavChunk returns a chunk header with the given size, followed by the
+// data and a pad byte when the data has an odd length
+func wavChunk(id string, size uint32, data []byte) []byte {
+	chunk := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], size)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func wavFormatChunk(size uint32, tag, channels uint16, rate uint32, bits uint16) []byte {
+	data := make([]byte, size)
+	binary.LittleEndian.PutUint16(data[0:], tag)
+	binary.LittleEndian.PutUint16(data[2:], channels)
+	binary.LittleEndian.PutUint32(data[4:], rate)
+	binary.LittleEndian.PutUint16(data[14:], bits)
+	return wavChunk("fmt ", size, data)
+}
+
+func wavExtensibleChunk(tag, bits uint16) []byte {
+	chunk := wavFormatChunk(40, WAV_FORMAT_EXTENSIBLE, 2, 48000, bits)
+	binary.LittleEndian.PutUint16(chunk[8+24:], tag)
+	return chunk
+}
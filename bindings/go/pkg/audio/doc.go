@@ -0,0 +1,5 @@
+/*
+audio provides decoding and conversion of audio data into the 16 kHz mono
+float32 samples expected by the whisper package.
+*/
+package audio
@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// EncodeWAV writes 16 kHz mono samples as a 16-bit linear PCM WAV file
+func EncodeWAV(w io.Writer, data []float32) error {
+	size := uint32(len(data) * 2)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+size)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], WAV_FORMAT_PCM)
+	binary.LittleEndian.PutUint16(header[22:], 1)
+	binary.LittleEndian.PutUint32(header[24:], SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], SampleRate*2)
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], size)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	// Write the samples in blocks
+	buf := make([]byte, 0, 8192)
+	for i, v := range data {
+		sample := int16(math.Max(-32768, math.Min(32767, math.Round(float64(v)*32768))))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(sample))
+		if len(buf) == cap(buf) || i == len(data)-1 {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+
+	// Return success
+	return nil
+}
@@ -0,0 +1,34 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeWAV(t *testing.T) {
+	samples := []float32{0, 0.5, -0.5, 1, -1}
+	var buf bytes.Buffer
+	if err := EncodeWAV(&buf, samples); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	format, size, err := ReadWAVHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	} else if format != (Format{ENCODING_S16LE, SampleRate, 1}) {
+		t.Fatalf("unexpected format %v", format)
+	} else if size != int64(len(samples)*2) {
+		t.Fatalf("unexpected size %d", size)
+	}
+	result, err := DecodeRaw(r, format)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result) != len(samples) {
+		t.Fatalf("expected %d samples, got %d", len(samples), len(result))
+	}
+	for i, v := range samples {
+		if diff := result[i] - v; diff < -1.0/32768 || diff > 1.0/32768 {
+			t.Errorf("sample %d is %v, expected %v", i, result[i], v)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// powerSpectrum returns the power of each frequency bin of a frame, which is
+// windowed with a Hann window and zero-padded to n samples. n must be a
+// power of two, and len(result) is n/2+1.
+func powerSpectrum(frame []float32, n int) []float64 {
+	buf := make([]complex128, n)
+	for i, v := range frame {
+		if i >= n {
+			break
+		}
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(frame)-1))
+		buf[i] = complex(float64(v)*w, 0)
+	}
+	fft(buf)
+
+	result := make([]float64, n/2+1)
+	for i := range result {
+		v := cmplx.Abs(buf[i])
+		result[i] = v * v
+	}
+	return result
+}
+
+// fft performs an in-place radix-2 fast fourier transform
+func fft(buf []complex128) {
+	n := len(buf)
+
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			buf[i], buf[j] = buf[j], buf[i]
+		}
+	}
+
+	// Butterflies
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := buf[start+k], buf[start+k+size/2]*w
+				buf[start+k], buf[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
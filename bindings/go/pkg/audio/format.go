@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Encoding is the sample encoding of a raw audio stream
+type Encoding uint
+
+// Format describes a raw (headerless) audio stream
+type Format struct {
+	Encoding   Encoding
+	SampleRate int
+	Channels   int
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	ENCODING_NONE  Encoding = iota
+	ENCODING_ULAW           // G.711 mu-law, 8 bits per sample
+	ENCODING_ALAW           // G.711 A-law, 8 bits per sample
+	ENCODING_S16LE          // Signed 16-bit little-endian PCM
+	ENCODING_F32LE          // 32-bit little-endian float PCM
+)
+
+var encodingNames = map[Encoding]string{
+	ENCODING_ULAW:  "ulaw",
+	ENCODING_ALAW:  "alaw",
+	ENCODING_S16LE: "s16le",
+	ENCODING_F32LE: "f32le",
+}
+
+var encodingAliases = map[string]Encoding{
+	"ulaw":  ENCODING_ULAW,
+	"mulaw": ENCODING_ULAW,
+	"pcmu":  ENCODING_ULAW,
+	"alaw":  ENCODING_ALAW,
+	"pcma":  ENCODING_ALAW,
+	"s16le": ENCODING_S16LE,
+	"f32le": ENCODING_F32LE,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// ParseFormat parses a format description of the form encoding[:rate[:channels]],
+// for example "ulaw", "alaw:8000:1" or "s16le:48000:2". When omitted, the
+// sample rate and number of channels default to the natural values for the
+// encoding.
+func ParseFormat(v string) (Format, error) {
+	var format Format
+
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(v)), ":")
+	if len(parts) > 3 {
+		return format, fmt.Errorf("%w: %q", ErrInvalidFormat, v)
+	}
+	if encoding, exists := encodingAliases[parts[0]]; !exists {
+		return format, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, parts[0])
+	} else {
+		format = DefaultFormat(encoding)
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if rate, err := strconv.ParseUint(parts[1], 10, 32); err != nil || rate == 0 {
+			return format, fmt.Errorf("%w: %q", ErrUnsupportedRate, parts[1])
+		} else {
+			format.SampleRate = int(rate)
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if channels, err := strconv.ParseUint(parts[2], 10, 16); err != nil || channels == 0 {
+			return format, fmt.Errorf("%w: %q", ErrUnsupportedChannels, parts[2])
+		} else {
+			format.Channels = int(channels)
+		}
+	}
+
+	// Return success
+	return format, nil
+}
+
+// DefaultFormat returns the format with the natural sample rate and
+// number of channels for an encoding
+func DefaultFormat(encoding Encoding) Format {
+	switch encoding {
+	case ENCODING_ULAW, ENCODING_ALAW:
+		return Format{Encoding: encoding, SampleRate: TelephonyRate, Channels: 1}
+	default:
+		return Format{Encoding: encoding, SampleRate: SampleRate, Channels: 1}
+	}
+}
+
+// FormatForPath returns the format implied by a raw stream filename
+// extension (.ulaw, .alaw, etc) or false if the extension is not recognized
+func FormatForPath(path string) (Format, bool) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch ext {
+	case "ul", "ulaw", "mulaw", "mu", "pcmu":
+		return DefaultFormat(ENCODING_ULAW), true
+	case "al", "alaw", "pcma":
+		return DefaultFormat(ENCODING_ALAW), true
+	}
+	return Format{}, false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// BytesPerSample returns the size of a single sample for one channel,
+// or zero if the encoding is unknown
+func (e Encoding) BytesPerSample() int {
+	switch e {
+	case ENCODING_ULAW, ENCODING_ALAW:
+		return 1
+	case ENCODING_S16LE:
+		return 2
+	case ENCODING_F32LE:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// BytesPerFrame returns the size of one sample across all channels
+func (f Format) BytesPerFrame() int {
+	return f.Encoding.BytesPerSample() * f.Channels
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (e Encoding) String() string {
+	if name, exists := encodingNames[e]; exists {
+		return name
+	}
+	return "none"
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%v:%d:%d", f.Encoding, f.SampleRate, f.Channels)
+}
@@ -0,0 +1,71 @@
+package audio
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Lookup tables from 8-bit G.711 codes to normalized float32 samples
+var (
+	ulawTable = makeTable(ULawToLinear)
+	alawTable = makeTable(ALawToLinear)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ULawToLinear expands a G.711 mu-law code into a 16-bit linear sample
+func ULawToLinear(u byte) int16 {
+	u = ^u
+	exponent := (u >> 4) & 0x07
+	mantissa := int32(u & 0x0F)
+	sample := (((mantissa << 3) + 0x84) << exponent) - 0x84
+	if u&0x80 != 0 {
+		return int16(-sample)
+	}
+	return int16(sample)
+}
+
+// ALawToLinear expands a G.711 A-law code into a 16-bit linear sample
+func ALawToLinear(a byte) int16 {
+	a ^= 0x55
+	exponent := (a >> 4) & 0x07
+	mantissa := int32(a & 0x0F)
+	var sample int32
+	if exponent == 0 {
+		sample = (mantissa << 4) + 0x08
+	} else {
+		sample = ((mantissa << 4) + 0x108) << (exponent - 1)
+	}
+	if a&0x80 == 0 {
+		return int16(-sample)
+	}
+	return int16(sample)
+}
+
+// DecodeULaw converts mu-law encoded data into float32 samples in the range [-1, 1]
+func DecodeULaw(data []byte) []float32 {
+	return decodeTable(ulawTable, data)
+}
+
+// DecodeALaw converts A-law encoded data into float32 samples in the range [-1, 1]
+func DecodeALaw(data []byte) []float32 {
+	return decodeTable(alawTable, data)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func makeTable(fn func(byte) int16) [256]float32 {
+	var table [256]float32
+	for i := range table {
+		table[i] = float32(fn(byte(i))) / 32768
+	}
+	return table
+}
+
+func decodeTable(table [256]float32, data []byte) []float32 {
+	result := make([]float32, len(data))
+	for i, v := range data {
+		result[i] = table[v]
+	}
+	return result
+}
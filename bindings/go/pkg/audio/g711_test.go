@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"testing"
+)
+
+func TestG711ToLinear(t *testing.T) {
+	tests := []struct {
+		code byte
+		ulaw int16
+		alaw int16
+	}{
+		{0x00, -32124, -5504},
+		{0x7F, 0, -848},
+		{0x80, 32124, 5504},
+		{0xFF, 0, 848},
+		{0x55, -716, -8},
+		{0xD5, 716, 8},
+		{0x2A, -5372, -32256},
+		{0xAA, 5372, 32256},
+	}
+	for _, test := range tests {
+		if v := ULawToLinear(test.code); v != test.ulaw {
+			t.Errorf("ULawToLinear(0x%02X) = %d, expected %d", test.code, v, test.ulaw)
+		}
+		if v := ALawToLinear(test.code); v != test.alaw {
+			t.Errorf("ALawToLinear(0x%02X) = %d, expected %d", test.code, v, test.alaw)
+		}
+	}
+}
+
+func TestG711RoundTrip(t *testing.T) {
+	// Every code should survive a round-trip through a reference encoder,
+	// except mu-law negative zero which encodes as positive zero
+	for i := 0; i < 256; i++ {
+		code := byte(i)
+		expected := code
+		if code == 0x7F {
+			expected = 0xFF
+		}
+		if v := linearToULaw(ULawToLinear(code)); v != expected {
+			t.Errorf("mu-law 0x%02X round-trips to 0x%02X", code, v)
+		}
+		if v := linearToALaw(ALawToLinear(code)); v != code {
+			t.Errorf("A-law 0x%02X round-trips to 0x%02X", code, v)
+		}
+	}
+}
+
+func TestDecodeG711(t *testing.T) {
+	data := []byte{0x00, 0xFF, 0x80}
+	for _, samples := range [][]float32{DecodeULaw(data), DecodeALaw(data)} {
+		if len(samples) != len(data) {
+			t.Fatalf("expected %d samples, got %d", len(data), len(samples))
+		}
+		for _, v := range samples {
+			if v < -1 || v > 1 {
+				t.Errorf("sample %v out of range", v)
+			}
+		}
+	}
+	if v := DecodeULaw(data)[0]; v != float32(-32124)/32768 {
+		t.Errorf("unexpected mu-law sample %v", v)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// linearToULaw is the G.711 reference mu-law encoder
+func linearToULaw(sample int16) byte {
+	const bias, clip = 0x84, 32635
+	v := int32(sample)
+	sign := byte(0)
+	if v < 0 {
+		v, sign = -v, 0x80
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+	exponent := byte(7)
+	for mask := int32(0x4000); v&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := byte(v>>(exponent+3)) & 0x0F
+	return ^(sign | exponent<<4 | mantissa)
+}
+
+// linearToALaw is the G.711 reference A-law encoder
+func linearToALaw(sample int16) byte {
+	v := int32(sample)
+	sign := byte(0x80)
+	if v < 0 {
+		v, sign = -v-1, 0
+	}
+	var code byte
+	if v < 256 {
+		code = byte(v >> 4)
+	} else {
+		exponent := byte(1)
+		for v>>(exponent+8) != 0 && exponent < 7 {
+			exponent++
+		}
+		code = exponent<<4 | byte(v>>(exponent+3))&0x0F
+	}
+	return (sign | code) ^ 0x55
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Reader decodes a raw audio stream into 16 kHz mono samples as data
+// arrives, without waiting for the end of the stream
+type Reader struct {
+	r         io.Reader
+	format    Format
+	resampler *Resampler
+	read      []byte    // Buffer used for each read
+	buf       []byte    // Bytes read which do not yet make a whole frame
+	samples   []float32 // Decoded samples not yet returned
+	err       error
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	readerBufSize = 1024 * 16 // Size of the buffer used for each read
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewReader returns a reader which decodes a raw stream in the given format
+func NewReader(r io.Reader, format Format) (*Reader, error) {
+	reader := new(Reader)
+	if format.Channels < 1 {
+		return nil, ErrUnsupportedChannels
+	} else if format.BytesPerFrame() == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEncoding, format.Encoding)
+	} else if format.SampleRate != SampleRate {
+		if resampler, err := NewResampler(format.SampleRate, SampleRate); err != nil {
+			return nil, err
+		} else {
+			reader.resampler = resampler
+		}
+	}
+	reader.r = r
+	reader.format = format
+	reader.read = make([]byte, readerBufSize-readerBufSize%format.BytesPerFrame())
+
+	// Return success
+	return reader, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Format returns the format of the underlying stream
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Read reads up to len(data) samples into data. It blocks only until some
+// samples are available, and returns io.EOF once the stream has ended and
+// all samples have been returned.
+func (r *Reader) Read(data []float32) (int, error) {
+	for len(r.samples) == 0 && r.err == nil {
+		r.fill()
+	}
+	n := copy(data, r.samples)
+	r.samples = r.samples[n:]
+	if n == 0 {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// fill reads from the underlying stream once and decodes any whole frames
+func (r *Reader) fill() {
+	frame := r.format.BytesPerFrame()
+	n, err := r.r.Read(r.read)
+	r.buf = append(r.buf, r.read[:n]...)
+
+	// Decode whole frames
+	whole := len(r.buf) - len(r.buf)%frame
+	samples := Downmix(decodeSamples(r.buf[:whole], r.format.Encoding), r.format.Channels)
+	r.buf = append(r.buf[:0], r.buf[whole:]...)
+	if r.resampler != nil {
+		samples = r.resampler.Write(samples)
+	}
+
+	// At the end of the stream, flush any remaining samples
+	if err != nil {
+		if r.resampler != nil {
+			samples = append(samples, r.resampler.Flush()...)
+		}
+		r.err = err
+	}
+	r.samples = append(r.samples, samples...)
+}
@@ -0,0 +1,213 @@
+package audio
+
+import (
+	"math"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Telephony audio carries 300-3400 Hz, so the interpolation filter
+	// keeps that band flat and rolls off before the 4 kHz image.
+	telephonyCutoff = 3700.0 // Hz
+	telephonyTaps   = 63     // Filter length, odd so the delay is a whole sample
+)
+
+// Interpolation filter for 8 kHz to 16 kHz conversion, designed at 16 kHz
+var telephonyFilter = lowpass(telephonyCutoff/SampleRate, telephonyTaps)
+
+const (
+	resamplerZeros    = 12   // Zero crossings of the sinc kernel on each side
+	resamplerRolloff  = 0.92 // Passband as a fraction of the lower Nyquist frequency
+	resamplerMaxRatio = 64   // Maximum ratio between input and output rates
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Resampler converts a stream of mono samples between two sample rates.
+// Samples are written in arbitrary sized blocks, and the resampler keeps
+// enough history between blocks that the output is identical to
+// converting the whole stream at once.
+type Resampler struct {
+	step   float64   // Input samples per output sample
+	cutoff float64   // Normalized cutoff frequency, in cycles per input sample
+	half   int       // Half width of the kernel, in input samples
+	buf    []float32 // Input samples not yet consumed
+	pos    float64   // Position of the next output sample within buf
+	in     int       // Number of input samples written
+	out    int       // Number of output samples returned
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewResampler returns a resampler from one sample rate to another
+func NewResampler(from, to int) (*Resampler, error) {
+	if from <= 0 || to <= 0 || from > to*resamplerMaxRatio || to > from*resamplerMaxRatio {
+		return nil, ErrUnsupportedRate
+	}
+
+	r := new(Resampler)
+	r.step = float64(from) / float64(to)
+
+	// Band-limit to the lower of the two Nyquist frequencies. Telephony audio
+	// uses the same passband as Upsample8k.
+	if from == TelephonyRate {
+		r.cutoff = telephonyCutoff / float64(from)
+	} else {
+		r.cutoff = resamplerRolloff * 0.5 * math.Min(1, 1/r.step)
+	}
+	r.half = int(math.Ceil(resamplerZeros / (2 * r.cutoff)))
+
+	// Prime with silence so the first output lines up with the first input
+	r.buf = make([]float32, r.half)
+	r.pos = float64(r.half)
+
+	// Return success
+	return r, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Upsample8k converts 8 kHz telephony audio into 16 kHz audio. Samples are
+// interpolated with a band-limited filter tuned for the G.711 voice band,
+// and the output is aligned with the input so timestamps are preserved.
+func Upsample8k(data []float32) []float32 {
+	result := make([]float32, len(data)*2)
+	delay := len(telephonyFilter) / 2
+	for n := range result {
+		// Only every other sample in the zero-stuffed input is non-zero,
+		// so sum over the taps which line up with a real input sample
+		var sum float64
+		k := (n + delay) & 1
+		for ; k < len(telephonyFilter); k += 2 {
+			i := n + delay - k
+			if i < 0 {
+				break
+			}
+			if i>>1 < len(data) {
+				sum += telephonyFilter[k] * float64(data[i>>1])
+			}
+		}
+		result[n] = float32(2 * sum)
+	}
+	return result
+}
+
+// Resample converts mono samples at the given sample rate into 16 kHz samples
+func Resample(data []float32, rate int) ([]float32, error) {
+	switch rate {
+	case SampleRate:
+		return data, nil
+	case TelephonyRate:
+		return Upsample8k(data), nil
+	}
+	r, err := NewResampler(rate, SampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return append(r.Write(data), r.Flush()...), nil
+}
+
+// Write adds input samples and returns any output samples which can be
+// computed so far
+func (r *Resampler) Write(data []float32) []float32 {
+	r.buf = append(r.buf, data...)
+	r.in += len(data)
+
+	var result []float32
+	for int(r.pos)+r.half < len(r.buf) {
+		result = append(result, r.sample(r.pos))
+		r.pos += r.step
+	}
+
+	// Drop input which is no longer within reach of the kernel
+	if drop := int(r.pos) - r.half; drop > 0 {
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.pos -= float64(drop)
+	}
+	r.out += len(result)
+	return result
+}
+
+// Flush returns the remaining output samples at the end of the stream
+// and resets the resampler
+func (r *Resampler) Flush() []float32 {
+	// Pad with silence so the kernel reaches past the last input sample,
+	// and return only the output which the input accounts for
+	n := int(math.Ceil(float64(r.in)/r.step)) - r.out
+	result := r.Write(make([]float32, r.half+1))
+	if n < len(result) {
+		result = result[:n]
+	}
+
+	// Reset
+	r.buf = make([]float32, r.half)
+	r.pos = float64(r.half)
+	r.in, r.out = 0, 0
+	return result
+}
+
+// Downmix averages interleaved multi-channel samples into mono samples
+func Downmix(data []float32, channels int) []float32 {
+	if channels <= 1 {
+		return data
+	}
+	result := make([]float32, len(data)/channels)
+	for i := range result {
+		var sum float32
+		for _, v := range data[i*channels : (i+1)*channels] {
+			sum += v
+		}
+		result[i] = sum / float32(channels)
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sample evaluates the band-limited input signal at a fractional position
+// using a Blackman-windowed sinc kernel
+func (r *Resampler) sample(pos float64) float32 {
+	var sum float64
+	center := int(pos)
+	for i := center - r.half + 1; i <= center+r.half; i++ {
+		x := pos - float64(i)
+		if x <= -float64(r.half) || x >= float64(r.half) {
+			continue
+		}
+		v := 2 * r.cutoff
+		if x != 0 {
+			v = math.Sin(2*math.Pi*r.cutoff*x) / (math.Pi * x)
+		}
+		w := 0.42 + 0.5*math.Cos(math.Pi*x/float64(r.half)) + 0.08*math.Cos(2*math.Pi*x/float64(r.half))
+		sum += v * w * float64(r.buf[i])
+	}
+	return float32(sum)
+}
+
+// lowpass returns a Blackman-windowed sinc filter with unity DC gain, where
+// cutoff is the normalized cutoff frequency (cycles per sample)
+func lowpass(cutoff float64, taps int) []float64 {
+	result := make([]float64, taps)
+	center := float64(taps-1) / 2
+	var sum float64
+	for i := range result {
+		x := float64(i) - center
+		v := 2 * cutoff
+		if x != 0 {
+			v = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		w := 0.42 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(taps-1)) + 0.08*math.Cos(4*math.Pi*float64(i)/float64(taps-1))
+		result[i] = v * w
+		sum += result[i]
+	}
+	for i := range result {
+		result[i] /= sum
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestUpsample8k(t *testing.T) {
+	// Away from the edges the output should be the same tone at 16 kHz
+	data := sine(1000, TelephonyRate, TelephonyRate/2)
+	result := Upsample8k(data)
+	if len(result) != 2*len(data) {
+		t.Fatalf("expected %d samples, got %d", 2*len(data), len(result))
+	}
+	expected := sine(1000, SampleRate, len(result))
+	for i := 1000; i < len(result)-1000; i++ {
+		if math.Abs(float64(result[i]-expected[i])) > 0.01 {
+			t.Fatalf("sample %d is %v, expected %v", i, result[i], expected[i])
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	// Half a second of audio at each rate
+	for _, rate := range []int{8000, 16000, 22050, 44100, 48000} {
+		data := sine(1000, rate, rate/2)
+		result, err := Resample(data, rate)
+		if err != nil {
+			t.Errorf("%d Hz: unexpected error: %v", rate, err)
+			continue
+		}
+		if len(result) != SampleRate/2 {
+			t.Errorf("%d Hz: expected %d samples, got %d", rate, SampleRate/2, len(result))
+			continue
+		}
+
+		// Away from the edges the output should be the same tone at 16 kHz
+		expected := sine(1000, SampleRate, len(result))
+		for i := 1000; i < len(result)-1000; i++ {
+			if math.Abs(float64(result[i]-expected[i])) > 0.01 {
+				t.Errorf("%d Hz: sample %d is %v, expected %v", rate, i, result[i], expected[i])
+				break
+			}
+		}
+	}
+}
+
+func TestResamplerBlocks(t *testing.T) {
+	// Writing in blocks should give the same output as writing at once
+	data := sine(440, 44100, 10000)
+	r, err := NewResampler(44100, SampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whole := append(r.Write(data), r.Flush()...)
+	var blocks []float32
+	for i := 0; i < len(data); i += 777 {
+		end := i + 777
+		if end > len(data) {
+			end = len(data)
+		}
+		blocks = append(blocks, r.Write(data[i:end])...)
+	}
+	blocks = append(blocks, r.Flush()...)
+	if len(blocks) != len(whole) {
+		t.Fatalf("expected %d samples, got %d", len(whole), len(blocks))
+	}
+	for i := range whole {
+		if math.Abs(float64(blocks[i]-whole[i])) > 1e-6 {
+			t.Fatalf("sample %d is %v, expected %v", i, blocks[i], whole[i])
+		}
+	}
+}
+
+func TestNewResampler(t *testing.T) {
+	for _, rate := range []int{0, -1, 16000 * (resamplerMaxRatio + 1)} {
+		if _, err := NewResampler(rate, SampleRate); !errors.Is(err, ErrUnsupportedRate) {
+			t.Errorf("%d Hz: expected ErrUnsupportedRate, got %v", rate, err)
+		}
+	}
+}
+
+func TestDownmix(t *testing.T) {
+	data := []float32{1, 0, 0.5, 0.5, -1, 0}
+	if result := Downmix(data, 1); len(result) != len(data) {
+		t.Errorf("mono: expected %d samples, got %d", len(data), len(result))
+	}
+	result := Downmix(data, 2)
+	expected := []float32{0.5, 0.5, -0.5}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(result))
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("sample %d is %v, expected %v", i, result[i], expected[i])
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sine returns n samples of a half-scale tone at the given frequency
+func sine(freq float64, rate, n int) []float32 {
+	result := make([]float32, n)
+	for i := range result {
+		result[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return result
+}
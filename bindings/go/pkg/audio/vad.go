@@ -0,0 +1,211 @@
+package audio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// VAD detects regions of speech in 16 kHz mono audio, using frame energy
+// above the noise floor and spectral flatness. Decisions are smoothed with a
+// hangover, so short pauses within speech are not cut.
+type VAD struct {
+	// Aggressiveness in filtering out non-speech, from 0 (keep anything
+	// which might be speech) to 3 (keep only clear speech)
+	Aggressiveness int
+
+	// Speech continues for this long after the last speech frame
+	Hangover time.Duration
+
+	// Speech regions shorter than this are dropped
+	MinSpeech time.Duration
+
+	// Gaps between speech regions shorter than this are bridged
+	MinSilence time.Duration
+
+	// Added before and after each speech region
+	Padding time.Duration
+}
+
+// Region is a span of audio, relative to the start of the audio
+type Region struct {
+	Start, End time.Duration
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	vadFrame      = 25 * time.Millisecond // Analysis frame length
+	vadHop        = 10 * time.Millisecond // Analysis frame step
+	vadFFTSize    = 512                   // FFT size, at least the frame length
+	vadLowHz      = 250                   // Lowest frequency used for flatness
+	vadHighHz     = 4000                  // Highest frequency used for flatness
+	vadSilenceDB  = -60                   // Frames quieter than this are never speech
+	vadPercentile = 0.1                   // Percentile of frame energy taken as the noise floor
+)
+
+// Thresholds for each level of aggressiveness
+var (
+	vadMarginDB = [...]float64{6, 9, 12, 15}             // Energy above the noise floor
+	vadFlatness = [...]float64{0.6, 0.5, 0.4, 0.3}       // Maximum spectral flatness
+	vadHangover = [...]time.Duration{300, 200, 150, 100} // In milliseconds
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewVAD returns a voice activity detector with defaults for the given
+// aggressiveness, from 0 to 3
+func NewVAD(aggressiveness int) *VAD {
+	vad := new(VAD)
+	vad.Aggressiveness = clampAggressiveness(aggressiveness)
+	vad.Hangover = vadHangover[vad.Aggressiveness] * time.Millisecond
+	vad.MinSpeech = 250 * time.Millisecond
+	vad.MinSilence = 500 * time.Millisecond
+	vad.Padding = 200 * time.Millisecond
+	return vad
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Detect returns the regions of speech in 16 kHz mono audio, in order
+func (vad *VAD) Detect(data []float32) []Region {
+	frame, hop := toSamples(vadFrame), toSamples(vadHop)
+	if len(data) < frame {
+		return nil
+	}
+
+	// Measure each frame
+	n := (len(data)-frame)/hop + 1
+	energy := make([]float64, n)
+	flatness := make([]float64, n)
+	for i := range energy {
+		energy[i], flatness[i] = measureFrame(data[i*hop : i*hop+frame])
+	}
+
+	// Classify each frame against the noise floor
+	aggr := clampAggressiveness(vad.Aggressiveness)
+	floor := percentile(energy, vadPercentile)
+	speech := make([]bool, n)
+	for i := range speech {
+		switch {
+		case energy[i] < vadSilenceDB:
+			speech[i] = false
+		case energy[i] > floor+2*vadMarginDB[aggr]:
+			speech[i] = true
+		default:
+			speech[i] = energy[i] > floor+vadMarginDB[aggr] && flatness[i] < vadFlatness[aggr]
+		}
+	}
+
+	// Smooth with a hangover, and collect regions of speech
+	var result []Region
+	hangover := int(vad.Hangover / vadHop)
+	for i, start, last := 0, -1, -1; i <= n; i++ {
+		if i < n && speech[i] {
+			if start < 0 {
+				start = i
+			}
+			last = i
+		} else if start >= 0 && (i == n || i-last > hangover) {
+			result = append(result, Region{
+				Start: time.Duration(start) * vadHop,
+				End:   time.Duration(last)*vadHop + vadFrame,
+			})
+			start = -1
+		}
+	}
+
+	// Bridge short gaps, drop short regions and pad
+	result = mergeRegions(result, vad.MinSilence)
+	length := toDuration(len(data))
+	filtered := result[:0]
+	for _, region := range result {
+		if region.End-region.Start < vad.MinSpeech {
+			continue
+		}
+		if region.Start -= vad.Padding; region.Start < 0 {
+			region.Start = 0
+		}
+		if region.End += vad.Padding; region.End > length {
+			region.End = length
+		}
+		filtered = append(filtered, region)
+	}
+	return mergeRegions(filtered, 0)
+}
+
+// Duration returns the length of the region
+func (r Region) Duration() time.Duration {
+	return r.End - r.Start
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// measureFrame returns the energy of a frame in dBFS and the spectral
+// flatness of the speech band, from 0 (tonal) to 1 (noise)
+func measureFrame(frame []float32) (float64, float64) {
+	var sum float64
+	for _, v := range frame {
+		sum += float64(v) * float64(v)
+	}
+	energy := 10 * math.Log10(sum/float64(len(frame))+1e-10)
+
+	power := powerSpectrum(frame, vadFFTSize)
+	lo, hi := vadLowHz*vadFFTSize/SampleRate, vadHighHz*vadFFTSize/SampleRate
+	var logSum, linSum float64
+	for _, p := range power[lo : hi+1] {
+		logSum += math.Log(p + 1e-12)
+		linSum += p + 1e-12
+	}
+	bins := float64(hi - lo + 1)
+	flatness := math.Exp(logSum/bins) / (linSum / bins)
+
+	return energy, flatness
+}
+
+// mergeRegions joins regions separated by less than gap
+func mergeRegions(regions []Region, gap time.Duration) []Region {
+	result := regions[:0]
+	for _, region := range regions {
+		if n := len(result); n > 0 && region.Start-result[n-1].End <= gap {
+			if region.End > result[n-1].End {
+				result[n-1].End = region.End
+			}
+		} else {
+			result = append(result, region)
+		}
+	}
+	return result
+}
+
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+func clampAggressiveness(v int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v > 3:
+		return 3
+	default:
+		return v
+	}
+}
+
+func toSamples(v time.Duration) int {
+	return int(v * SampleRate / time.Second)
+}
+
+func toDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / SampleRate
+}
@@ -0,0 +1,150 @@
+package whisper
+
+import (
+	"strings"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// AlignedWord is a word of a reference transcript with its position in the
+// audio
+type AlignedWord struct {
+	Word
+
+	// Position of the word in the reference transcript
+	Index int
+
+	// False when the decoder did not reach the word, or could not place it
+	// in time
+	Aligned bool
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Align returns timestamps for each word of a known transcript of the audio.
+// The transcript is tokenized, and the decoder is forced to emit exactly
+// those tokens while choosing where the timestamps go. Words which could not
+// be aligned are returned with Aligned set to false.
+func (context *context) Align(s State, data []float32, text string) ([]AlignedWord, error) {
+	if context.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+	ctx := context.model.ctx
+
+	// Tokenize each word separately, so that tokens map back to words
+	words := strings.Fields(text)
+	var ref []whisper.Token
+	var owner []int
+	for i, word := range words {
+		tokens, err := tokenize(ctx, " "+word)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens {
+			ref = append(ref, token)
+			owner = append(owner, i)
+		}
+	}
+
+	// Decode greedily without fallback, with token timestamps, and process
+	// all of the audio
+	forced := *context
+	forced.vad, forced.gate = nil, nil
+	forced.params.SetTokenTimestamps(true)
+	forced.params.SetTemperatureFallback(0)
+	forced.params.SetSingleSegment(false)
+	forced.params.SetMaxSegmentLength(0)
+
+	// Allow only the next reference token, timestamps and the end of the
+	// window. Each window starts from the tokens emitted by the windows
+	// before it.
+	st := s.(*state).st
+	eot, beg := ctx.Whisper_token_eot(), ctx.Whisper_token_beg()
+	start := 0
+	forced.filter = func(tokens []whisper.TokenData, logits []float32) {
+		if len(tokens) == 0 {
+			start = countText(st, eot)
+		}
+		next := start
+		for _, token := range tokens {
+			if token.Id() < eot {
+				next++
+			}
+		}
+		for id := whisper.Token(0); id < beg && int(id) < len(logits); id++ {
+			if id != eot && (next >= len(ref) || id != ref[next]) {
+				logits[id] = negInf
+			}
+		}
+	}
+	segments, err := forced.process(s, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match emitted text tokens to the reference in order
+	matched := make([][]Token, len(words))
+	next := 0
+	for _, segment := range segments {
+		for _, token := range segment.Tokens {
+			if token.IsSpecial() || next >= len(ref) {
+				continue
+			}
+			if whisper.Token(token.Id) == ref[next] {
+				matched[owner[next]] = append(matched[owner[next]], token)
+			}
+			next++
+		}
+	}
+
+	// Make words from their tokens
+	result := make([]AlignedWord, len(words))
+	for i, word := range words {
+		result[i].Text = word
+		result[i].Index = i
+		if n := len(matched[i]); n > 0 {
+			aligned := Segment{Tokens: matched[i]}.Words()
+			result[i].Start = matched[i][0].Start
+			result[i].End = matched[i][n-1].End
+			if len(aligned) > 0 {
+				result[i].Confidence = aligned[0].Confidence
+			}
+			result[i].Aligned = n == countOwned(owner, i) && result[i].End > result[i].Start
+		}
+	}
+
+	// Return success
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// countText returns the number of text tokens in the segments of the state
+func countText(st *whisper.State, eot whisper.Token) int {
+	n := 0
+	for i := 0; i < st.Whisper_full_n_segments(); i++ {
+		for j := 0; j < st.Whisper_full_n_tokens(i); j++ {
+			if st.Whisper_full_get_token_id(i, j) < eot {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// countOwned returns the number of reference tokens belonging to word i
+func countOwned(owner []int, i int) int {
+	n := 0
+	for _, v := range owner {
+		if v == i {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package whisper
+
+import (
+	"sort"
+	"strings"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// bias is a phrase boosted when decoding
+type bias struct {
+	tokens []whisper.Token
+	value  float32
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set phrases to boost when decoding, such as domain terms and names which
+// are otherwise misrecognized. Each phrase is tokenized, and while decoding
+// the bias is added to the logit of the next token of any phrase which the
+// text so far partially matches, so that a phrase which has been started is
+// completed. Negative values discourage completing a phrase. Set to nil to
+// remove all phrases.
+func (context *context) SetLogitBias(phrases map[string]float32) error {
+	if context.model.ctx == nil {
+		return ErrInternalAppError
+	}
+
+	// Sort phrases so that decoding is repeatable
+	keys := make([]string, 0, len(phrases))
+	for phrase := range phrases {
+		keys = append(keys, phrase)
+	}
+	sort.Strings(keys)
+
+	// Tokenize each phrase both within a sentence, where it follows a
+	// space, and at the start of the text
+	var result []bias
+	for _, phrase := range keys {
+		text := strings.TrimSpace(phrase)
+		if text == "" || phrases[phrase] == 0 {
+			continue
+		}
+		for _, text := range []string{" " + text, text} {
+			tokens, err := tokenize(context.model.ctx, text)
+			if err != nil {
+				return err
+			} else if len(tokens) > 0 {
+				result = append(result, bias{tokens, phrases[phrase]})
+			}
+		}
+	}
+	context.bias = result
+
+	// Return success
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// applyBias boosts the phrases against the text tokens decoded so far
+func (context *context) applyBias(tokens []whisper.TokenData, logits []float32) {
+	eot := context.model.ctx.Whisper_token_eot()
+	text := make([]whisper.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Id() < eot {
+			text = append(text, token.Id())
+		}
+	}
+	biasLogits(context.bias, text, logits)
+}
+
+// biasLogits adds the bias of each phrase to the logit of its next token,
+// after the longest part of the phrase which ends the text. Phrases which
+// are not started, or which the text ends with, are not boosted.
+func biasLogits(phrases []bias, text []whisper.Token, logits []float32) {
+	for _, phrase := range phrases {
+		if hasSuffix(text, phrase.tokens) {
+			continue
+		}
+		k := len(phrase.tokens) - 1
+		if k > len(text) {
+			k = len(text)
+		}
+		for ; k > 0; k-- {
+			if hasSuffix(text, phrase.tokens[:k]) {
+				break
+			}
+		}
+		if k == 0 {
+			continue
+		}
+		if id := int(phrase.tokens[k]); id < len(logits) {
+			logits[id] += phrase.value
+		}
+	}
+}
+
+// hasSuffix returns true if text ends with the tokens of suffix
+func hasSuffix(text, suffix []whisper.Token) bool {
+	if len(suffix) > len(text) {
+		return false
+	}
+	text = text[len(text)-len(suffix):]
+	for i := range suffix {
+		if text[i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
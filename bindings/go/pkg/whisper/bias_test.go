@@ -0,0 +1,41 @@
+package whisper
+
+import (
+	"testing"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+func TestBiasLogits(t *testing.T) {
+	phrases := []bias{
+		{tokens: []whisper.Token{10, 11, 12}, value: 5},
+		{tokens: []whisper.Token{10, 13}, value: -2},
+		{tokens: []whisper.Token{14}, value: 3},
+		{tokens: []whisper.Token{15, 99}, value: 1},
+	}
+	tests := []struct {
+		name     string
+		text     []whisper.Token
+		expected map[int]float32
+	}{
+		{"empty", nil, nil},
+		{"not started", []whisper.Token{1, 2}, nil},
+		{"single token phrase", []whisper.Token{14}, nil},
+		{"started", []whisper.Token{1, 10}, map[int]float32{11: 5, 13: -2}},
+		{"partial", []whisper.Token{10, 11}, map[int]float32{12: 5}},
+		{"complete", []whisper.Token{10, 11, 12}, nil},
+		{"started again", []whisper.Token{10, 11, 12, 10}, map[int]float32{11: 5, 13: -2}},
+		{"broken off", []whisper.Token{10, 11, 1}, nil},
+		{"out of range", []whisper.Token{15}, nil},
+	}
+	for _, test := range tests {
+		logits := make([]float32, 20)
+		biasLogits(phrases, test.text, logits)
+		for id, v := range logits {
+			if v != test.expected[id] {
+				t.Errorf("%s: logit %d is %v, expected %v", test.name, id, v, test.expected[id])
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package whisper
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type commandRecognizer struct {
+	context   *context
+	phrases   []string
+	commands  []command
+	root      *trieNode
+	threshold float32
+}
+
+// command is one concrete phrase of the grammar, with alternatives chosen
+// and slots filled in
+type command struct {
+	index int
+	text  string
+	slots map[string]string
+}
+
+// trieNode is a node of a token trie, with the indices of the entries
+// which end there
+type trieNode struct {
+	next map[whisper.Token]*trieNode
+	ends []int
+}
+
+// Make sure commandRecognizer adheres to the interface
+var _ CommandRecognizer = (*commandRecognizer)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultCommandThreshold = 0.3
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newCommandRecognizer(parent *context, phrases []string, slots map[string][]string) (*commandRecognizer, error) {
+	if parent.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+	recognizer := new(commandRecognizer)
+	recognizer.phrases = phrases
+	recognizer.threshold = defaultCommandThreshold
+
+	// Copy the context so the parameters don't leak into it. Each command
+	// is decoded greedily as a single segment, without past text.
+	context := *parent
+	context.vad, context.gate, context.bias = nil, nil, nil
+	context.params.SetSingleSegment(true)
+	context.params.SetNoContext(true)
+	context.params.SetTemperatureFallback(0)
+	context.params.SetMaxSegmentLength(0)
+	context.params.SetPrintRealtime(false)
+	context.params.SetPrintProgress(false)
+	recognizer.context = &context
+
+	// Expand the grammar into commands
+	for i, phrase := range phrases {
+		commands, err := expandPhrase(phrase, slots)
+		if err != nil {
+			return nil, err
+		}
+		for _, command := range commands {
+			command.index = i
+			recognizer.commands = append(recognizer.commands, command)
+		}
+	}
+	if len(recognizer.commands) == 0 {
+		return nil, ErrInvalidGrammar
+	}
+
+	// Build the trie of tokens, as lower case and capitalized text which
+	// follows a space
+	recognizer.root = newTrieNode()
+	for i, command := range recognizer.commands {
+		for _, text := range []string{command.text, capitalize(command.text)} {
+			tokens, err := tokenize(context.model.ctx, " "+text)
+			if err != nil {
+				return nil, err
+			}
+			recognizer.root.insert(tokens, i)
+		}
+	}
+
+	// Return success
+	return recognizer, nil
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{next: make(map[whisper.Token]*trieNode)}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the score below which a command is rejected, from 0 to 1
+func (recognizer *commandRecognizer) SetThreshold(v float32) {
+	recognizer.threshold = v
+}
+
+// Recognize decodes a short utterance constrained to the commands, and
+// returns the best matching command
+func (recognizer *commandRecognizer) Recognize(s State, data []float32) (Command, error) {
+	ctx := recognizer.context.model.ctx
+	eot, beg := ctx.Whisper_token_eot(), ctx.Whisper_token_beg()
+
+	// Allow only tokens which continue a command, and the end of text once
+	// a command is complete. Timestamps are left to the decoder. The log
+	// probability of each allowed token before constraining is kept to
+	// score the result.
+	logprobs := make(map[int]map[whisper.Token]float64)
+	context := *recognizer.context
+	context.filter = func(tokens []whisper.TokenData, logits []float32) {
+		node, n := recognizer.root, 0
+		for _, token := range tokens {
+			if id := token.Id(); id < eot && node != nil {
+				node, n = node.next[id], n+1
+			}
+		}
+
+		// Normalize the unconstrained logits
+		norm := logSumExp(logits)
+		allowed := make(map[whisper.Token]float64)
+		if node != nil {
+			for id := range node.next {
+				if int(id) < len(logits) {
+					allowed[id] = float64(logits[id]) - norm
+				}
+			}
+		}
+		logprobs[n] = allowed
+
+		for id := whisper.Token(0); id < beg && int(id) < len(logits); id++ {
+			if _, exists := allowed[id]; exists {
+				continue
+			} else if id == eot && node != nil && len(node.ends) > 0 {
+				continue
+			}
+			logits[id] = negInf
+		}
+	}
+	segments, err := context.process(s, data)
+	if err != nil {
+		return Command{}, err
+	}
+
+	// Follow the decoded text through the trie, and score it by the
+	// geometric mean of the unconstrained token probabilities
+	node, n, logp := recognizer.root, 0, 0.0
+	for _, segment := range segments {
+		for _, token := range segment.Tokens {
+			if token.IsSpecial() || node == nil {
+				continue
+			}
+			id := whisper.Token(token.Id)
+			logp += logprobs[n][id]
+			node, n = node.next[id], n+1
+		}
+	}
+	if node == nil || len(node.ends) == 0 || n == 0 {
+		return Command{}, ErrCommandRejected
+	}
+	cmd := recognizer.commands[node.ends[0]]
+	result := Command{
+		Index:  cmd.index,
+		Phrase: recognizer.phrases[cmd.index],
+		Text:   cmd.text,
+		Slots:  cmd.slots,
+		Score:  float32(math.Exp(logp / float64(n))),
+	}
+
+	// Reject commands with a low score
+	if result.Score < recognizer.threshold {
+		return result, ErrCommandRejected
+	}
+
+	// Return success
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// insert adds the tokens of entry i to the trie
+func (node *trieNode) insert(tokens []whisper.Token, i int) {
+	for _, token := range tokens {
+		next, exists := node.next[token]
+		if !exists {
+			next = newTrieNode()
+			node.next[token] = next
+		}
+		node = next
+	}
+	for _, j := range node.ends {
+		if j == i {
+			return
+		}
+	}
+	node.ends = append(node.ends, i)
+}
+
+// expandPhrase returns the commands of a phrase, which may contain
+// alternatives such as "(on|off)", where an empty alternative makes the
+// group optional, and slots such as "{device}" which are filled with each of
+// their values
+func expandPhrase(phrase string, slots map[string][]string) ([]command, error) {
+	result := []command{{}}
+	for rest := phrase; rest != ""; {
+		var choices []string
+		var slot string
+		switch i := strings.IndexAny(rest, "({"); {
+		case i < 0:
+			choices, rest = []string{rest}, ""
+		case i > 0:
+			choices, rest = []string{rest[:i]}, rest[i:]
+		case rest[0] == '(':
+			end := strings.IndexByte(rest, ')')
+			if end < 0 {
+				return nil, ErrInvalidGrammar
+			}
+			choices, rest = strings.Split(rest[1:end], "|"), rest[end+1:]
+		default:
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, ErrInvalidGrammar
+			}
+			slot, rest = strings.TrimSpace(rest[1:end]), rest[end+1:]
+			if values, exists := slots[slot]; !exists || len(values) == 0 {
+				return nil, ErrInvalidGrammar
+			} else {
+				choices = values
+			}
+		}
+
+		// Extend every command so far with every choice
+		next := make([]command, 0, len(result)*len(choices))
+		for _, cmd := range result {
+			for _, choice := range choices {
+				extended := command{text: cmd.text + choice, slots: cmd.slots}
+				if slot != "" {
+					extended.slots = make(map[string]string, len(cmd.slots)+1)
+					for k, v := range cmd.slots {
+						extended.slots[k] = v
+					}
+					extended.slots[slot] = strings.TrimSpace(choice)
+				}
+				next = append(next, extended)
+			}
+		}
+		result = next
+	}
+
+	// Normalize spacing, and drop empty commands
+	commands := result[:0]
+	for _, cmd := range result {
+		if cmd.text = strings.Join(strings.Fields(cmd.text), " "); cmd.text != "" {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands, nil
+}
+
+// capitalize returns text with the first letter in upper case
+func capitalize(text string) string {
+	r, n := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[n:]
+}
+
+// logSumExp returns the log of the sum of the exponent of the values
+func logSumExp(values []float32) float64 {
+	peak := math.Inf(-1)
+	for _, v := range values {
+		if float64(v) > peak {
+			peak = float64(v)
+		}
+	}
+	if math.IsInf(peak, -1) {
+		return peak
+	}
+	var sum float64
+	for _, v := range values {
+		sum += math.Exp(float64(v) - peak)
+	}
+	return peak + math.Log(sum)
+}
@@ -0,0 +1,107 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+func TestExpandPhrase(t *testing.T) {
+	slots := map[string][]string{
+		"device": {"lights", "fan"},
+		"empty":  {},
+	}
+	tests := []struct {
+		phrase   string
+		expected []string
+		err      error
+	}{
+		{"stop", []string{"stop"}, nil},
+		{"  go   home ", []string{"go home"}, nil},
+		{"turn (on|off)", []string{"turn on", "turn off"}, nil},
+		{"(please|) stop", []string{"please stop", "stop"}, nil},
+		{"turn (on|off) the {device}", []string{
+			"turn on the lights device=lights", "turn on the fan device=fan",
+			"turn off the lights device=lights", "turn off the fan device=fan",
+		}, nil},
+		{"{ device } (up|down)", []string{"lights up device=lights", "lights down device=lights", "fan up device=fan", "fan down device=fan"}, nil},
+		{"(|)", nil, nil},
+		{"turn (on|off", nil, ErrInvalidGrammar},
+		{"open {door", nil, ErrInvalidGrammar},
+		{"open {door}", nil, ErrInvalidGrammar},
+		{"open {empty}", nil, ErrInvalidGrammar},
+	}
+	for _, test := range tests {
+		commands, err := expandPhrase(test.phrase, slots)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: expected error %v, got %v", test.phrase, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.phrase, err)
+			continue
+		}
+		var result []string
+		for _, cmd := range commands {
+			text := cmd.text
+			keys := make([]string, 0, len(cmd.slots))
+			for k := range cmd.slots {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				text += fmt.Sprintf(" %s=%s", k, cmd.slots[k])
+			}
+			result = append(result, text)
+		}
+		if strings.Join(result, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%q: commands are %q, expected %q", test.phrase, result, test.expected)
+		}
+	}
+}
+
+func TestTrieInsert(t *testing.T) {
+	root := newTrieNode()
+	root.insert([]whisper.Token{1, 2}, 0)
+	root.insert([]whisper.Token{1, 2, 3}, 1)
+	root.insert([]whisper.Token{1, 2}, 2)
+	root.insert([]whisper.Token{1, 2}, 0)
+
+	node := root.next[1].next[2]
+	if len(node.ends) != 2 || node.ends[0] != 0 || node.ends[1] != 2 {
+		t.Errorf("unexpected entries %v", node.ends)
+	}
+	if ends := node.next[3].ends; len(ends) != 1 || ends[0] != 1 {
+		t.Errorf("unexpected entries %v", ends)
+	}
+	if len(root.ends) != 0 || len(root.next) != 1 {
+		t.Errorf("unexpected root %+v", root)
+	}
+}
+
+func TestLogSumExp(t *testing.T) {
+	tests := []struct {
+		values   []float32
+		expected float64
+	}{
+		{nil, math.Inf(-1)},
+		{[]float32{float32(math.Inf(-1))}, math.Inf(-1)},
+		{[]float32{0}, 0},
+		{[]float32{0, 0}, math.Log(2)},
+		{[]float32{1000, 1000}, 1000 + math.Log(2)},
+		{[]float32{-1000, 0}, 0},
+	}
+	for _, test := range tests {
+		if v := logSumExp(test.values); math.Abs(v-test.expected) > 1e-6 && v != test.expected {
+			t.Errorf("logSumExp(%v) = %v, expected %v", test.values, v, test.expected)
+		}
+	}
+}
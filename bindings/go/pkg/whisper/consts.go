@@ -11,11 +11,15 @@ import (
 // ERRORS
 
 var (
-	ErrUnableToLoadModel    = errors.New("unable to load model")
-	ErrInternalAppError     = errors.New("internal application error")
-	ErrProcessingFailed     = errors.New("processing failed")
-	ErrUnsupportedLanguage  = errors.New("unsupported language")
-	ErrModelNotMultilingual = errors.New("model is not multilingual")
+	ErrUnableToLoadModel     = errors.New("unable to load model")
+	ErrInternalAppError      = errors.New("internal application error")
+	ErrProcessingFailed      = errors.New("processing failed")
+	ErrUnsupportedLanguage   = errors.New("unsupported language")
+	ErrModelNotMultilingual  = errors.New("model is not multilingual")
+	ErrInvalidGrammar        = errors.New("invalid command grammar")
+	ErrCommandRejected       = errors.New("command rejected")
+	ErrNoKeywords            = errors.New("no keywords")
+	ErrUnsupportedTranscript = errors.New("unsupported transcript version")
 )
 
 ///////////////////////////////////////////////////////////////////////////////
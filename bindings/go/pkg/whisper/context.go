@@ -8,6 +8,7 @@ import (
 
 	// Bindings
 	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -17,6 +18,15 @@ type context struct {
 	n      int
 	model  *model
 	params whisper.Params
+	vad    *audio.VAD
+	gate   *audio.VAD
+	bias   []bias                               // Phrases boosted when decoding
+	filter func([]whisper.TokenData, []float32) // Logits filter, or nil
+	events bool                                 // Move annotations into events
+	guard  *Guard                               // Retry hallucinated segments, or nil
+
+	suppressIds  []whisper.Token   // Tokens never decoded
+	suppressText [][]whisper.Token // Token sequences never decoded
 }
 
 type state struct {
@@ -75,6 +85,26 @@ func (context *context) SetLanguage(lang string) error {
 	return nil
 }
 
+func (context *context) NewStreamer() Streamer {
+	return newStreamer(context)
+}
+
+func (context *context) NewCommandRecognizer(phrases []string, slots map[string][]string) (CommandRecognizer, error) {
+	if recognizer, err := newCommandRecognizer(context, phrases, slots); err != nil {
+		return nil, err
+	} else {
+		return recognizer, nil
+	}
+}
+
+func (context *context) NewSpotter(keywords []string) (Spotter, error) {
+	if spotter, err := newSpotter(context, keywords); err != nil {
+		return nil, err
+	} else {
+		return spotter, nil
+	}
+}
+
 func (context *context) IsMultilingual() bool {
 	return context.model.IsMultilingual()
 }
@@ -145,6 +175,25 @@ func (context *context) SetSuppressNonSpeechTokens(b bool) {
 	context.params.SetSuppressNonSpeechTokens(b)
 }
 
+// Set tinydiarize speaker turn detection, which needs a tinydiarize model
+func (context *context) SetTinydiarize(v bool) {
+	context.params.SetTinydiarize(v)
+}
+
+// Set voice activity detection. When set, only the regions of speech are
+// processed and timestamps are mapped back to the original audio. Set to nil
+// to process all audio.
+func (context *context) SetVAD(vad *audio.VAD) {
+	context.vad = vad
+}
+
+// Set voice activity detection for encoder windows. When set, each 30 second
+// window without speech is skipped rather than encoded. Set to nil to encode
+// all windows.
+func (context *context) SetSilenceGate(vad *audio.VAD) {
+	context.gate = vad
+}
+
 // ResetTimings resets the mode timings. Should be called before processing
 func (context *context) ResetTimings() {
 	context.model.ctx.Whisper_reset_timings()
@@ -172,15 +221,29 @@ func (context *context) Process(
 	if context.model.ctx == nil {
 		return nil, ErrInternalAppError
 	}
-
-	if err := context.model.ctx.Whisper_full_with_state(s.(*state).st, context.params, data); err != nil {
+	var segments []Segment
+	var err error
+	if context.vad != nil {
+		segments, err = context.processSpeech(s, data)
+	} else {
+		segments, err = context.process(s, data)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	num_segments := s.(*state).st.Whisper_full_n_segments()
-	segments := make([]Segment, num_segments)
-	for i := 0; i < num_segments; i++ {
-		segments[i] = toSegment(context.model.ctx, s.(*state).st, i)
+	// Decode hallucinated segments again
+	if context.guard != nil {
+		if segments, err = context.retryHallucinations(s, data, segments); err != nil {
+			return nil, err
+		}
+	}
+
+	// Move annotations of non-speech sounds into events
+	if context.events {
+		for i := range segments {
+			segments[i] = splitEvents(segments[i])
+		}
 	}
 
 	// Return success
@@ -249,6 +312,79 @@ func (context *context) IsLANG(t Token, lang string) bool {
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// process runs the model on all of the sample data
+func (context *context) process(s State, data []float32) ([]Segment, error) {
+	if context.gate != nil {
+		return context.processGated(s, data)
+	}
+	if err := context.model.ctx.Whisper_full_with_state_callbacks(s.(*state).st, context.fullParams(), data, whisper.FullCallbacks{
+		LogitsFilter: context.logitsFilter(),
+	}); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return context.toSegments(s.(*state).st), nil
+}
+
+// fullParams returns the parameters for processing, where non-speech
+// tokens are not suppressed in non-speech events mode
+func (context *context) fullParams() whisper.Params {
+	params := context.params
+	if context.events {
+		params.SetSuppressNonSpeechTokens(false)
+	}
+	return params
+}
+
+// logitsFilter returns the filter applied to logits when decoding, or nil
+// when there is nothing to filter
+func (context *context) logitsFilter() func([]whisper.TokenData, []float32) {
+	var filters []func([]whisper.TokenData, []float32)
+	if len(context.bias) > 0 {
+		filters = append(filters, context.applyBias)
+	}
+	if len(context.suppressIds) > 0 || len(context.suppressText) > 0 {
+		filters = append(filters, context.applySuppress)
+	}
+	if context.filter != nil {
+		filters = append(filters, context.filter)
+	}
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0]
+	default:
+		return func(tokens []whisper.TokenData, logits []float32) {
+			for _, filter := range filters {
+				filter(tokens, logits)
+			}
+		}
+	}
+}
+
+// toSegments returns all segments from the state, with special tokens marked
+func (context *context) toSegments(state *whisper.State) []Segment {
+	num_segments := state.Whisper_full_n_segments()
+	segments := make([]Segment, num_segments)
+	var lang string
+	if id := state.Whisper_full_lang_id(); id >= 0 {
+		lang = whisper.Whisper_lang_str(id)
+	}
+	for i := 0; i < num_segments; i++ {
+		segments[i] = toSegment(context.model.ctx, state, i)
+		segments[i].Language = lang
+		for j, token := range segments[i].Tokens {
+			segments[i].Tokens[j].Special = !context.IsText(token)
+			if context.IsSOLM(token) {
+				segments[i].SpeakerTurnNext = true
+			}
+		}
+	}
+	return segments
+}
+
 func toSegment(ctx *whisper.Context, state *whisper.State, n int) Segment {
 	return Segment{
 		Num:    n,
@@ -274,3 +410,67 @@ func toTokens(ctx *whisper.Context, state *whisper.State, n int) []Token {
 	}
 	return result
 }
+
+// tokenize returns the tokens of text
+func tokenize(ctx *whisper.Context, text string) ([]whisper.Token, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tokens := make([]whisper.Token, len(text)+1)
+	n, err := ctx.Whisper_tokenize(text, tokens)
+	if err != nil {
+		return nil, err
+	}
+	return tokens[:n], nil
+}
+
+// trimAudio returns the samples selected by the offset and duration in
+// params, and the position of the first of them, and clears both so that
+// the samples are processed from their start
+func trimAudio(params *whisper.Params, data []float32) ([]float32, int) {
+	start, end := toSamples(time.Duration(params.Offset())*time.Millisecond), len(data)
+	if duration := params.Duration(); duration > 0 {
+		if stop := start + toSamples(time.Duration(duration)*time.Millisecond); stop < end {
+			end = stop
+		}
+	}
+	if start > end {
+		start = end
+	}
+	params.SetOffset(0)
+	params.SetDuration(0)
+	return data[start:end], start
+}
+
+// toSamples converts a duration into a number of samples
+func toSamples(v time.Duration) int {
+	return int(v * SampleRate / time.Second)
+}
+
+// toDuration converts a number of samples into a duration
+func toDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / SampleRate
+}
+
+// shiftSegment returns a copy of a segment with timestamps moved by offset
+func shiftSegment(segment Segment, offset time.Duration) Segment {
+	segment.Start += offset
+	segment.End += offset
+	tokens := make([]Token, len(segment.Tokens))
+	for i, token := range segment.Tokens {
+		token.Start += offset
+		token.End += offset
+		tokens[i] = token
+	}
+	segment.Tokens = tokens
+	if segment.Events != nil {
+		events := make([]Event, len(segment.Events))
+		for i, event := range segment.Events {
+			event.Start += offset
+			event.End += offset
+			events[i] = event
+		}
+		segment.Events = events
+	}
+	return segment
+}
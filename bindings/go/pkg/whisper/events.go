@@ -0,0 +1,166 @@
+package whisper
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// EventType is the kind of a non-speech event
+type EventType int
+
+// Event is a non-speech sound, such as music or laughter, annotated in the
+// text as "[MUSIC]" or "(laughs)"
+type Event struct {
+	Type EventType
+
+	// The annotation without brackets
+	Text string
+
+	// Time beginning and end timestamps for the event.
+	Start, End time.Duration
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	EVENT_OTHER EventType = iota
+	EVENT_MUSIC
+	EVENT_LAUGHTER
+	EVENT_APPLAUSE
+	EVENT_NOISE
+	EVENT_SILENCE
+)
+
+var (
+	// Annotations in square brackets or parentheses
+	reAnnotation = regexp.MustCompile(`\[[^\[\]]+\]|\([^()]+\)`)
+
+	// Words which identify the type of an event, in lower case
+	eventWords = []struct {
+		t     EventType
+		words []string
+	}{
+		{EVENT_MUSIC, []string{"music", "song", "singing", "♪"}},
+		{EVENT_LAUGHTER, []string{"laugh", "chuckl", "giggl"}},
+		{EVENT_APPLAUSE, []string{"applau", "clap", "cheer"}},
+		{EVENT_SILENCE, []string{"silence", "blank_audio", "no speech", "inaudible"}},
+		{EVENT_NOISE, []string{"noise", "cough", "static", "sigh", "breath", "beep", "ringing"}},
+	}
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set non-speech events mode. When set, non-speech tokens are not
+// suppressed, and annotations of sounds such as "[MUSIC]" or "(laughs)" are
+// removed from the text of each segment and returned as its events.
+func (context *context) SetNonSpeechEvents(v bool) {
+	context.events = v
+}
+
+func (t EventType) String() string {
+	switch t {
+	case EVENT_MUSIC:
+		return "music"
+	case EVENT_LAUGHTER:
+		return "laughter"
+	case EVENT_APPLAUSE:
+		return "applause"
+	case EVENT_NOISE:
+		return "noise"
+	case EVENT_SILENCE:
+		return "silence"
+	default:
+		return "other"
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// splitEvents moves annotations from the text and tokens of a segment into
+// its events. Event timestamps are those of the annotation tokens, or of
+// the segment when the tokens have no timestamps.
+func splitEvents(segment Segment) Segment {
+	// Join the text tokens, recording where each one starts
+	var text strings.Builder
+	var offsets []int
+	var tokens []int
+	for i, token := range segment.Tokens {
+		if !token.IsSpecial() {
+			offsets = append(offsets, text.Len())
+			tokens = append(tokens, i)
+			text.WriteString(token.Text)
+		}
+	}
+	matches := reAnnotation.FindAllStringIndex(text.String(), -1)
+	if len(matches) == 0 {
+		return segment
+	}
+
+	// Make an event from each annotation, and remove its tokens
+	drop := make(map[int]bool)
+	events := append([]Event(nil), segment.Events...)
+	for _, match := range matches {
+		annotation := text.String()[match[0]:match[1]]
+		event := Event{
+			Text:  strings.TrimSpace(annotation[1 : len(annotation)-1]),
+			Start: segment.Start,
+			End:   segment.End,
+		}
+		event.Type = eventType(event.Text)
+
+		var first, last *Token
+		for j, offset := range offsets {
+			end := text.Len()
+			if j+1 < len(offsets) {
+				end = offsets[j+1]
+			}
+			if end <= match[0] || offset >= match[1] {
+				continue
+			}
+			token := &segment.Tokens[tokens[j]]
+			if first == nil {
+				first = token
+			}
+			last = token
+			if start := offset + len(token.Text) - len(strings.TrimLeft(token.Text, " ")); start >= match[0] && end <= match[1] {
+				drop[tokens[j]] = true
+			}
+		}
+		if first != nil && last.End > first.Start {
+			event.Start, event.End = first.Start, last.End
+		}
+		events = append(events, event)
+	}
+
+	// Keep the remaining text and tokens
+	segment.Text = strings.Join(strings.Fields(reAnnotation.ReplaceAllString(text.String(), " ")), " ")
+	kept := make([]Token, 0, len(segment.Tokens))
+	for i, token := range segment.Tokens {
+		if !drop[i] {
+			kept = append(kept, token)
+		}
+	}
+	segment.Tokens = kept
+	segment.Events = events
+	return segment
+}
+
+// eventType returns the type of event for the text of an annotation
+func eventType(text string) EventType {
+	text = strings.ToLower(text)
+	for _, entry := range eventWords {
+		for _, word := range entry.words {
+			if strings.Contains(text, word) {
+				return entry.t
+			}
+		}
+	}
+	return EVENT_OTHER
+}
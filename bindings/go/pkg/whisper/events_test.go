@@ -0,0 +1,108 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		timed  bool
+		text   string
+		kept   []string
+		events []Event
+	}{
+		{
+			"no events",
+			[]string{"[_BEG_]", " Hello", " there"}, true,
+			" Hello there", []string{"[_BEG_]", " Hello", " there"}, nil,
+		},
+		{
+			"music",
+			[]string{"[_BEG_]", " [", "MUS", "IC", "]", " Hello"}, true,
+			"Hello", []string{"[_BEG_]", " Hello"},
+			[]Event{{Type: EVENT_MUSIC, Text: "MUSIC", Start: 1 * time.Second, End: 5 * time.Second}},
+		},
+		{
+			"laughter after text",
+			[]string{" So", " funny", " (", "laughs", ")"}, true,
+			"So funny", []string{" So", " funny"},
+			[]Event{{Type: EVENT_LAUGHTER, Text: "laughs", Start: 2 * time.Second, End: 5 * time.Second}},
+		},
+		{
+			"untimed",
+			[]string{" [", "BLANK_AUDIO", "]"}, false,
+			"", nil,
+			[]Event{{Type: EVENT_SILENCE, Text: "BLANK_AUDIO", Start: 0, End: 10 * time.Second}},
+		},
+		{
+			"two events",
+			[]string{" [", "applause", "]", " thanks", " [", "door", "]"}, true,
+			"thanks", []string{" thanks"},
+			[]Event{
+				{Type: EVENT_APPLAUSE, Text: "applause", Start: 0, End: 3 * time.Second},
+				{Type: EVENT_OTHER, Text: "door", Start: 4 * time.Second, End: 7 * time.Second},
+			},
+		},
+	}
+	for _, test := range tests {
+		segment := Segment{End: 10 * time.Second}
+		var text strings.Builder
+		for i, token := range test.tokens {
+			if !isSpecialText(token) {
+				text.WriteString(token)
+			}
+			if test.timed {
+				segment.Tokens = append(segment.Tokens, Token{Text: token, Start: time.Duration(i) * time.Second, End: time.Duration(i+1) * time.Second})
+			} else {
+				segment.Tokens = append(segment.Tokens, Token{Text: token})
+			}
+		}
+		segment.Text = text.String()
+
+		result := splitEvents(segment)
+		if strings.TrimSpace(result.Text) != strings.TrimSpace(test.text) {
+			t.Errorf("%s: text is %q, expected %q", test.name, result.Text, test.text)
+		}
+		var kept []string
+		for _, token := range result.Tokens {
+			kept = append(kept, token.Text)
+		}
+		if strings.Join(kept, "|") != strings.Join(test.kept, "|") {
+			t.Errorf("%s: tokens are %q, expected %q", test.name, kept, test.kept)
+		}
+		if len(result.Events) != len(test.events) {
+			t.Errorf("%s: expected %d events, got %d", test.name, len(test.events), len(result.Events))
+			continue
+		}
+		for i, event := range result.Events {
+			if event != test.events[i] {
+				t.Errorf("%s: event %d is %+v, expected %+v", test.name, i, event, test.events[i])
+			}
+		}
+	}
+}
+
+func TestEventType(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected EventType
+	}{
+		{"MUSIC", EVENT_MUSIC},
+		{"♪", EVENT_MUSIC},
+		{"Laughter", EVENT_LAUGHTER},
+		{"chuckles", EVENT_LAUGHTER},
+		{"APPLAUSE", EVENT_APPLAUSE},
+		{"BLANK_AUDIO", EVENT_SILENCE},
+		{"coughs", EVENT_NOISE},
+		{"door slams", EVENT_OTHER},
+	}
+	for _, test := range tests {
+		if v := eventType(test.text); v != test.expected {
+			t.Errorf("eventType(%q) = %v, expected %v", test.text, v, test.expected)
+		}
+	}
+}
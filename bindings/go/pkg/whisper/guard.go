@@ -0,0 +1,315 @@
+package whisper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"math"
+	"strings"
+	"time"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Guard detects hallucinated segments, such as a phrase repeated in a loop
+// or text over silence, and decodes their audio again with different
+// settings. Zero values disable each check.
+type Guard struct {
+	// A phrase of up to four words repeated this many times in a row,
+	// within a segment or as consecutive segments, is a loop
+	MaxRepeats int
+
+	// Segments whose text compresses better than this ratio are repetitive
+	MaxCompression float64
+
+	// Segments whose average token log probability is below this are
+	// unreliable
+	MinLogProb float64
+
+	// If set, segments within audio with no speech are flagged
+	VAD *audio.VAD
+
+	// Flagged audio is decoded again with this temperature, and with beam
+	// search when the beam size is more than one
+	Temperature float32
+	BeamSize    int
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	guardMaxNGram    = 4                       // Longest phrase checked for loops
+	guardMinText     = 32                      // Shorter text is not checked for compression
+	guardMinRetry    = 1100 * time.Millisecond // Shortest audio decoded again
+	guardRetryBestOf = 5                       // Candidates when sampling with a temperature
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewGuard returns a hallucination guard with the thresholds used by
+// OpenAI Whisper, and voice activity detection with the given
+// aggressiveness from 0 to 3, or none when negative
+func NewGuard(aggressiveness int) *Guard {
+	guard := new(Guard)
+	guard.MaxRepeats = 4
+	guard.MaxCompression = 2.4
+	guard.MinLogProb = -1.0
+	if aggressiveness >= 0 {
+		guard.VAD = audio.NewVAD(aggressiveness)
+	}
+	guard.Temperature = 0.4
+	guard.BeamSize = 5
+	return guard
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set hallucination guard. When set, segments which look hallucinated are
+// decoded again, and marked as retried whichever result is kept. Set to nil
+// to disable.
+func (context *context) SetGuard(guard *Guard) {
+	context.guard = guard
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// retryHallucinations decodes the audio of flagged segments again, and
+// keeps whichever result is flagged less
+func (context *context) retryHallucinations(s State, data []float32, segments []Segment) ([]Segment, error) {
+	guard := context.guard
+
+	// Detect speech once, for checking both the original and retried
+	// segments
+	var regions []audio.Region
+	if guard.VAD != nil {
+		regions = guard.VAD.Detect(data)
+	}
+	flags := guard.check(regions, segments)
+
+	// Decode with a temperature or beam search, without the guard
+	retry := *context
+	retry.guard, retry.vad, retry.gate = nil, nil, nil
+	retry.params.SetTemperature(guard.Temperature)
+	retry.params.SetTemperatureFallback(0)
+	if guard.BeamSize > 1 {
+		retry.params.SetStrategy(whisper.SAMPLING_BEAM_SEARCH)
+		retry.params.SetBeamSize(guard.BeamSize)
+	} else {
+		retry.params.SetStrategy(whisper.SAMPLING_GREEDY)
+		retry.params.SetBestOf(guardRetryBestOf)
+	}
+	retry.params.SetOffset(0)
+	retry.params.SetDuration(0)
+
+	var result []Segment
+	for i := 0; i < len(segments); {
+		if flags[i] == 0 {
+			result = append(result, segments[i])
+			i++
+			continue
+		}
+
+		// Decode consecutive flagged segments together
+		j := i + 1
+		for j < len(segments) && flags[j] > 0 {
+			j++
+		}
+		start, end := toSamples(segments[i].Start), toSamples(segments[j-1].End)
+		if short := toSamples(guardMinRetry) - (end - start); short > 0 {
+			end += short
+		}
+		if start < 0 {
+			start = 0
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		retried, err := retry.process(s, data[start:end])
+		if err != nil {
+			return nil, err
+		}
+		offset := toDuration(start)
+		for k := range retried {
+			retried[k] = shiftSegment(retried[k], offset)
+		}
+
+		// Keep the result with fewer flags, dropping text over silence
+		result = append(result, guard.choose(regions, segments[i:j], flags[i:j], retried)...)
+		i = j
+	}
+
+	// Renumber
+	for i := range result {
+		result[i].Num = i
+	}
+
+	// Return success
+	return result, nil
+}
+
+// Reasons a segment is flagged
+const (
+	flagLoop = 1 << iota
+	flagCompression
+	flagLogProb
+	flagSilence
+)
+
+// choose returns the original or retried segments, whichever are flagged
+// less, without text over silence. The segments are marked as retried, and
+// whether the retried segments were used.
+func (guard *Guard) choose(regions []audio.Region, original []Segment, flags []int, retried []Segment) []Segment {
+	chosen, chosenFlags, used := original, flags, false
+	if retriedFlags := guard.check(regions, retried); countFlagged(retriedFlags) <= countFlagged(flags) {
+		chosen, chosenFlags, used = retried, retriedFlags, true
+	}
+	result := make([]Segment, 0, len(chosen))
+	for i, segment := range chosen {
+		if chosenFlags[i]&flagSilence != 0 {
+			continue
+		}
+		segment.Retried, segment.RetryUsed = true, used
+		result = append(result, segment)
+	}
+	return result
+}
+
+// check returns the reasons each segment is flagged, or zero. The regions
+// of speech are used when the guard has voice activity detection.
+func (guard *Guard) check(regions []audio.Region, segments []Segment) []int {
+	flags := make([]int, len(segments))
+
+	// Loops within segments, and consecutive segments with the same text
+	if guard.MaxRepeats > 1 {
+		for i, segment := range segments {
+			if hasLoop(strings.Fields(normalizeText(segment.Text)), guard.MaxRepeats) {
+				flags[i] |= flagLoop
+			}
+		}
+		for i := 0; i < len(segments); {
+			j, text := i+1, normalizeText(segments[i].Text)
+			for j < len(segments) && text != "" && normalizeText(segments[j].Text) == text {
+				j++
+			}
+			if j-i >= guard.MaxRepeats {
+				for k := i; k < j; k++ {
+					flags[k] |= flagLoop
+				}
+			}
+			i = j
+		}
+	}
+
+	// Compression ratio and log probability
+	for i, segment := range segments {
+		if guard.MaxCompression > 0 && len(segment.Text) >= guardMinText && compressionRatio(segment.Text) > guard.MaxCompression {
+			flags[i] |= flagCompression
+		}
+		if guard.MinLogProb < 0 {
+			if logp, ok := avgLogProb(segment); ok && logp < guard.MinLogProb {
+				flags[i] |= flagLogProb
+			}
+		}
+	}
+
+	// Text over audio without speech
+	if guard.VAD != nil {
+		for i, segment := range segments {
+			if segment.Text == "" {
+				continue
+			}
+			silent := true
+			for _, region := range regions {
+				if region.Start < segment.End && region.End > segment.Start {
+					silent = false
+					break
+				}
+			}
+			if silent {
+				flags[i] |= flagSilence
+			}
+		}
+	}
+
+	return flags
+}
+
+// hasLoop returns true if a phrase of up to four words repeats n times in
+// a row
+func hasLoop(words []string, n int) bool {
+	for size := 1; size <= guardMaxNGram; size++ {
+		for start := 0; start+size*n <= len(words); start++ {
+			repeats := 1
+			for next := start + size; next+size <= len(words) && equalWords(words[start:start+size], words[next:next+size]); next += size {
+				if repeats++; repeats >= n {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compressionRatio returns the length of text divided by its length when
+// compressed
+func compressionRatio(text string) float64 {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(text))
+	w.Close()
+	return float64(len(text)) / float64(buf.Len())
+}
+
+// avgLogProb returns the mean log probability of the text tokens of a
+// segment
+func avgLogProb(segment Segment) (float64, bool) {
+	var logp float64
+	var n int
+	for _, token := range segment.Tokens {
+		if !token.IsSpecial() {
+			logp += math.Log(math.Max(float64(token.P), 1e-10))
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return logp / float64(n), true
+}
+
+// normalizeText returns the words of text, ignoring case and punctuation
+func normalizeText(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = normalizeWord(word)
+	}
+	return strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+}
+
+// countFlagged returns the number of flagged segments
+func countFlagged(flags []int) int {
+	n := 0
+	for _, flag := range flags {
+		if flag != 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+)
+
+func TestHasLoop(t *testing.T) {
+	tests := []struct {
+		text     string
+		n        int
+		expected bool
+	}{
+		{"", 3, false},
+		{"thank you", 3, false},
+		{"no no no", 3, true},
+		{"no no", 3, false},
+		{"so I said no no no", 3, true},
+		{"thank you thank you thank you", 3, true},
+		{"thank you thank you", 3, false},
+		{"one two three four one two three four one two three four", 3, true},
+		{"a b c d e a b c d e a b c d e", 3, false},
+	}
+	for _, test := range tests {
+		if v := hasLoop(strings.Fields(test.text), test.n); v != test.expected {
+			t.Errorf("hasLoop(%q, %d) = %v, expected %v", test.text, test.n, v, test.expected)
+		}
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	guard := NewGuard(-1)
+	s := time.Second
+	tests := []struct {
+		name     string
+		guard    *Guard
+		regions  []audio.Region
+		segments []Segment
+		expected []int
+	}{
+		{
+			"clean",
+			guard, nil,
+			[]Segment{{Text: " Hello there, how are you?"}, {Text: " Fine, thanks."}},
+			[]int{0, 0},
+		},
+		{
+			"loop in segment",
+			guard, nil,
+			[]Segment{{Text: " Hello there."}, {Text: " yes yes yes yes"}},
+			[]int{0, flagLoop},
+		},
+		{
+			"repeated segments",
+			guard, nil,
+			[]Segment{{Text: " Thank you."}, {Text: " thank you"}, {Text: " Thank you!"}, {Text: " Thank you."}, {Text: " Bye."}},
+			[]int{flagLoop, flagLoop, flagLoop, flagLoop, 0},
+		},
+		{
+			"compression",
+			&Guard{MaxCompression: 2.4}, nil,
+			[]Segment{{Text: strings.Repeat(" the end of the line", 10)}},
+			[]int{flagCompression},
+		},
+		{
+			"log probability",
+			guard, nil,
+			[]Segment{{Text: " Hi", Tokens: []Token{{Text: " Hi", P: 0.1}, {Text: "[_BEG_]", P: 0.001}}}, {Text: " Hi", Tokens: []Token{{Text: " Hi", P: 0.9}}}},
+			[]int{flagLogProb, 0},
+		},
+		{
+			"silence",
+			&Guard{VAD: audio.NewVAD(1)}, []audio.Region{{Start: 2 * s, End: 3 * s}},
+			[]Segment{{Start: 0, End: s, Text: " Hello"}, {Start: s, End: 2 * s}, {Start: 1500 * time.Millisecond, End: 2500 * time.Millisecond, Text: " Hi"}},
+			[]int{flagSilence, 0, 0},
+		},
+	}
+	for _, test := range tests {
+		flags := test.guard.check(test.regions, test.segments)
+		for i := range test.expected {
+			if flags[i] != test.expected[i] {
+				t.Errorf("%s: segment %d has flags %d, expected %d", test.name, i, flags[i], test.expected[i])
+			}
+		}
+	}
+}
+
+func TestGuardChoose(t *testing.T) {
+	guard := &Guard{MaxRepeats: 3, VAD: audio.NewVAD(1)}
+	s := time.Second
+	regions := []audio.Region{{Start: 0, End: 2 * s}}
+	loop := Segment{Start: 0, End: s, Text: " no no no"}
+	tests := []struct {
+		name     string
+		original []Segment
+		retried  []Segment
+		expected []string
+		used     bool
+	}{
+		{"better", []Segment{loop}, []Segment{{Start: 0, End: s, Text: " no"}}, []string{" no"}, true},
+		{"equal", []Segment{loop}, []Segment{{Start: 0, End: s, Text: " yes yes yes"}}, []string{" yes yes yes"}, true},
+		{"worse", []Segment{loop}, []Segment{{Start: 0, End: s, Text: " a a a"}, {Start: s, End: 2 * s, Text: " b b b"}}, []string{" no no no"}, false},
+		{"silence", []Segment{loop}, []Segment{{Start: 0, End: s, Text: " no"}, {Start: 3 * s, End: 4 * s, Text: " Bye."}}, []string{" no"}, true},
+	}
+	for _, test := range tests {
+		flags := guard.check(regions, test.original)
+		result := guard.choose(regions, test.original, flags, test.retried)
+		var text []string
+		for _, segment := range result {
+			text = append(text, segment.Text)
+			if !segment.Retried || segment.RetryUsed != test.used {
+				t.Errorf("%s: segment %q retried %v and used %v, expected used %v", test.name, segment.Text, segment.Retried, segment.RetryUsed, test.used)
+			}
+		}
+		if strings.Join(text, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%s: result is %q, expected %q", test.name, text, test.expected)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package whisper
 import (
 	"io"
 	"time"
+
+	// Packages
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -46,12 +49,37 @@ type Context interface {
 	SetTokenTimestamps(bool)      // Set token timestamps flag
 	SetMaxTokensPerSegment(uint)  // Set max tokens per segment (0 = no limit)
 	SetSuppressNonSpeechTokens(bool)
+	SetTinydiarize(bool)                   // Set tinydiarize speaker turn detection, which needs a tinydiarize model
+	SetVAD(*audio.VAD)                     // Set voice activity detection, only speech is processed (nil = process all audio)
+	SetSilenceGate(*audio.VAD)             // Set voice activity detection to skip encoder windows without speech (nil = disabled)
+	SetLogitBias(map[string]float32) error // Set phrases to boost when decoding, with the bias added to each token's logit
+	SetSuppressTokens([]int)               // Set token ids which are never decoded (nil = none)
+	SetSuppressText([]string) error        // Set text which is never decoded (nil = none)
+	SetNonSpeechEvents(bool)               // Set non-speech events mode, where annotations such as "[MUSIC]" are returned as segment events
+	SetGuard(*Guard)                       // Set hallucination guard, which decodes repeated or unlikely text again (nil = disabled)
 
 	// Process mono audio data and return any errors.
 	// If defined, newly generated segments are passed to the
 	// callback function during processing.
 	Process(State, []float32) ([]Segment, error)
 
+	// Align a known transcript to mono audio, and return each word of the
+	// transcript with its timestamps.
+	Align(State, []float32, string) ([]AlignedWord, error)
+
+	// Return a new real-time streaming transcriber which uses the
+	// parameters of this context.
+	NewStreamer() Streamer
+
+	// Return a new recognizer for a list of command phrases. Phrases may
+	// contain alternatives such as "(on|off)", and slots such as "{device}"
+	// which are filled with the values given for the slot.
+	NewCommandRecognizer(phrases []string, slots map[string][]string) (CommandRecognizer, error)
+
+	// Return a new keyword spotter for continuous audio, which uses the
+	// parameters of this context.
+	NewSpotter(keywords []string) (Spotter, error)
+
 	IsBEG(Token) bool          // Test for "begin" token
 	IsSOT(Token) bool          // Test for "start of transcription" token
 	IsEOT(Token) bool          // Test for "end of transcription" token
@@ -68,6 +96,91 @@ type Context interface {
 	SystemInfo() string
 }
 
+// Streamer transcribes audio in real time. Samples are pushed as they
+// arrive and processed on a sliding window. Segments are final once their
+// window is complete, and partial until then. A Streamer is not safe for
+// concurrent use.
+type Streamer interface {
+	io.Closer
+
+	SetStep(time.Duration)   // Set how much new audio triggers processing
+	SetLength(time.Duration) // Set the length of the sliding window
+	SetKeep(time.Duration)   // Set the overlap kept between windows, at most the length less one step
+	SetAudioCtx(uint)        // Set the audio context size (0 = use default)
+
+	// Push mono audio data. Returns any segments which have become final
+	// and the current partial hypothesis, both with timestamps relative
+	// to the start of the stream.
+	Push([]float32) (final, partial []Segment, err error)
+
+	// Flush processes any remaining audio at the end of the stream and
+	// returns it as final segments.
+	Flush() ([]Segment, error)
+}
+
+// CommandRecognizer recognizes short utterances as one of a list of
+// commands, by constraining decoding to the tokens of the commands.
+type CommandRecognizer interface {
+	SetThreshold(float32) // Set the score below which a command is rejected, from 0 to 1
+
+	// Recognize mono audio of a short utterance, and return the best
+	// matching command. When its score is below the threshold, the command
+	// is returned with ErrCommandRejected.
+	Recognize(State, []float32) (Command, error)
+}
+
+// Command is a recognized command
+type Command struct {
+	// Index and text of the phrase in the list of commands
+	Index  int
+	Phrase string
+
+	// The text of the command, with alternatives chosen and slots filled
+	Text string
+
+	// The value of each slot
+	Slots map[string]string
+
+	// Geometric mean of the token probabilities, before constraining
+	Score float32
+}
+
+// Spotter detects keywords in continuous audio, processed on a sliding
+// window. Only detections are kept, not transcripts. A Spotter is not safe
+// for concurrent use.
+type Spotter interface {
+	io.Closer
+
+	SetStep(time.Duration)     // Set how much new audio triggers processing
+	SetLength(time.Duration)   // Set the length of the sliding window
+	SetSensitivity(float32)    // Set the sensitivity from 0 to 1, where higher values report keywords with lower confidence
+	SetDebounce(time.Duration) // Set the time after a detection during which the same keyword is not reported again
+
+	// Push mono audio data, and return any keywords detected with
+	// timestamps relative to the start of the stream.
+	Push([]float32) ([]Detection, error)
+
+	// Flush processes any remaining audio at the end of the stream.
+	Flush() ([]Detection, error)
+
+	// Listen reads a raw audio stream in the given format until it ends,
+	// and calls the function with each keyword detected.
+	Listen(io.Reader, audio.Format, func(Detection) error) error
+}
+
+// Detection is a keyword detected in audio
+type Detection struct {
+	// Index and text of the keyword
+	Index   int
+	Keyword string
+
+	// Time beginning and end timestamps for the keyword.
+	Start, End time.Duration
+
+	// Geometric mean of the token probabilities
+	Confidence float32
+}
+
 // Segment is the text result of a speech recognition.
 type Segment struct {
 	// Segment Number
@@ -81,6 +194,22 @@ type Segment struct {
 
 	// The tokens of the segment.
 	Tokens []Token
+
+	// Non-speech events in the segment, when enabled.
+	Events []Event
+
+	// Set when the segment was decoded again by the hallucination guard.
+	Retried bool
+
+	// Set when the result of decoding again was kept instead of the
+	// original result.
+	RetryUsed bool
+
+	// Set when tinydiarize predicts the next segment has a new speaker.
+	SpeakerTurnNext bool
+
+	// The language the segment was decoded in, such as "en".
+	Language string
 }
 
 // Token is a text or special token
@@ -89,4 +218,23 @@ type Token struct {
 	Text       string
 	P          float32
 	Start, End time.Duration
+
+	// Set for tokens which are not text, such as timestamps and the start
+	// and end of transcription
+	Special bool
+}
+
+// Word is one or more text tokens which make up a whole word
+type Word struct {
+	// The text of the word, without leading space
+	Text string
+
+	// Time beginning and end timestamps for the word.
+	Start, End time.Duration
+
+	// Geometric mean of the token probabilities
+	Confidence float32
+
+	// The tokens which make up the word
+	Tokens []Token
 }
@@ -0,0 +1,239 @@
+package whisper
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// LongOptions configures TranscribeLong. Zero values are replaced by
+// defaults.
+type LongOptions struct {
+	// Maximum length of audio processed in one call
+	Window time.Duration
+
+	// Audio shared between consecutive windows, so that words on a
+	// boundary are heard in full by at least one of them
+	Overlap time.Duration
+
+	// How far back from the end of a window to look for a quiet point
+	// to cut at
+	Search time.Duration
+
+	// If set, called with the final segments of each window as soon as
+	// it has been processed. Returning an error stops transcription.
+	Checkpoint func([]Segment) error
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultLongWindow  = 5 * time.Minute
+	defaultLongOverlap = 2 * time.Second
+	defaultLongSearch  = 15 * time.Second
+
+	energyFrame = 20 * time.Millisecond // Frame length used to find quiet points
+	dedupeWords = 32                    // Maximum number of words matched on a boundary
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// TranscribeLong transcribes audio of any length by processing it in bounded
+// windows cut at quiet points. The text of each window is used as the prompt
+// for the next, text repeated in the overlap between windows is removed, and
+// the result is a single list of segments with increasing timestamps.
+func TranscribeLong(ctx Context, data []float32, opts LongOptions) ([]Segment, error) {
+	parent, ok := ctx.(*context)
+	if !ok || parent.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+	opts = opts.withDefaults()
+
+	// Keep past text in the state between windows, and use token timestamps
+	// to trim the overlap. The offset and duration apply to the whole audio
+	// rather than to each window.
+	context := *parent
+	data, first := trimAudio(&context.params, data)
+	context.params.SetNoContext(false)
+	context.params.SetTokenTimestamps(true)
+	state := context.NewState()
+	defer state.Close()
+
+	var result []Segment
+	window, overlap, search := toSamples(opts.Window), toSamples(opts.Overlap), toSamples(opts.Search)
+	for start := 0; start < len(data); {
+		end := start + window
+		if end >= len(data) {
+			end = len(data)
+		} else {
+			end = quietest(data, end-search, end)
+		}
+
+		// Process the window
+		segments, err := context.Process(state, data[start:end])
+		if err != nil {
+			return nil, err
+		}
+		offset := toDuration(first + start)
+		for i := range segments {
+			segments[i] = shiftSegment(segments[i], offset)
+		}
+
+		// Remove text repeated from the previous window and renumber
+		segments = dedupe(result, segments)
+		for i := range segments {
+			segments[i].Num = len(result) + i
+		}
+		result = append(result, segments...)
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint(segments); err != nil {
+				return result, err
+			}
+		}
+
+		// Move to the next window
+		if end == len(data) {
+			break
+		} else if next := end - overlap; next > start {
+			start = next
+		} else {
+			start = end
+		}
+	}
+
+	// Return success
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (opts LongOptions) withDefaults() LongOptions {
+	if opts.Window <= 0 {
+		opts.Window = defaultLongWindow
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = defaultLongOverlap
+	}
+	if opts.Search <= 0 {
+		opts.Search = defaultLongSearch
+	}
+	if opts.Overlap >= opts.Window/2 {
+		opts.Overlap = opts.Window / 2
+	}
+	if opts.Search >= opts.Window/2 {
+		opts.Search = opts.Window / 2
+	}
+	return opts
+}
+
+// quietest returns the sample position in the middle of the frame with the
+// lowest energy between from and to
+func quietest(data []float32, from, to int) int {
+	frame := toSamples(energyFrame)
+	if from < 0 {
+		from = 0
+	}
+	if to > len(data) {
+		to = len(data)
+	}
+	if to-from < frame {
+		return to
+	}
+
+	best, lowest := to, math.Inf(1)
+	for i := from; i+frame <= to; i += frame / 2 {
+		var energy float64
+		for _, v := range data[i : i+frame] {
+			energy += float64(v) * float64(v)
+		}
+		if energy < lowest {
+			best, lowest = i+frame/2, energy
+		}
+	}
+	return best
+}
+
+// dedupe removes text from the start of segments which repeats the end of
+// prev, and clamps timestamps so they never go backwards
+func dedupe(prev, segments []Segment) []Segment {
+	if len(prev) == 0 {
+		return segments
+	}
+	end := prev[len(prev)-1].End
+
+	// Words at the end of the previous window which could be repeated
+	var tail []string
+	for i := len(prev) - 1; i >= 0 && len(tail) < dedupeWords; i-- {
+		tail = append(strings.Fields(prev[i].Text), tail...)
+	}
+
+	result := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.Start < end {
+			// Drop segments which are entirely repeated, or entirely within
+			// the previous window
+			words := strings.Fields(segment.Text)
+			n := matchWords(tail, words)
+			if n == len(words) || segment.End <= end {
+				continue
+			}
+
+			// Straddles the boundary, so drop any repeated words
+			if n > 0 {
+				segment.Text = strings.Join(words[n:], " ")
+				segment.Tokens = trimTokens(segment.Tokens, end)
+			}
+			segment.Start = end
+		}
+		if segment.End < segment.Start {
+			segment.End = segment.Start
+		}
+		if segment.Text != "" {
+			result = append(result, segment)
+			end = segment.End
+		}
+	}
+	return result
+}
+
+// matchWords returns the largest n such that the first n words equal the
+// last n words of tail, ignoring case and punctuation
+func matchWords(tail, words []string) int {
+	for n := len(words); n > 0; n-- {
+		if n > len(tail) {
+			continue
+		}
+		match := true
+		for i := 0; i < n && match; i++ {
+			match = normalizeWord(tail[len(tail)-n+i]) == normalizeWord(words[i])
+		}
+		if match {
+			return n
+		}
+	}
+	return 0
+}
+
+// trimTokens removes tokens which are centered before t
+func trimTokens(tokens []Token, t time.Duration) []Token {
+	result := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		if (token.Start+token.End)/2 >= t {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}))
+}
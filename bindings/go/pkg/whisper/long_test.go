@@ -0,0 +1,115 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupe(t *testing.T) {
+	s := time.Second
+	prev := []Segment{
+		{Start: 0, End: 2 * s, Text: " The quick brown fox"},
+		{Start: 2 * s, End: 4 * s, Text: " jumps over the lazy dog."},
+	}
+	tests := []struct {
+		name     string
+		prev     []Segment
+		segments []Segment
+		expected []string
+		starts   []time.Duration
+	}{
+		{
+			"first window",
+			nil,
+			[]Segment{{Start: 0, End: s, Text: " Hello"}},
+			[]string{" Hello"}, []time.Duration{0},
+		},
+		{
+			"after the boundary",
+			prev,
+			[]Segment{{Start: 5 * s, End: 6 * s, Text: " Next."}},
+			[]string{" Next."}, []time.Duration{5 * s},
+		},
+		{
+			"repeated",
+			prev,
+			[]Segment{{Start: 3 * s, End: 5 * s, Text: " the lazy dog"}, {Start: 5 * s, End: 6 * s, Text: " Next."}},
+			[]string{" Next."}, []time.Duration{5 * s},
+		},
+		{
+			"straddles the boundary",
+			prev,
+			[]Segment{{Start: 3 * s, End: 6 * s, Text: " Lazy dog. And then"}},
+			[]string{"And then"}, []time.Duration{4 * s},
+		},
+		{
+			"within the previous window",
+			prev,
+			[]Segment{{Start: 2 * s, End: 3 * s, Text: " something else"}, {Start: 3 * s, End: 5 * s, Text: " different"}},
+			[]string{" different"}, []time.Duration{4 * s},
+		},
+		{
+			"empty text",
+			prev,
+			[]Segment{{Start: 5 * s, End: 6 * s, Text: ""}},
+			nil, nil,
+		},
+	}
+	for _, test := range tests {
+		result := dedupe(test.prev, test.segments)
+		var text []string
+		var starts []time.Duration
+		for _, segment := range result {
+			text = append(text, segment.Text)
+			starts = append(starts, segment.Start)
+			if segment.End < segment.Start {
+				t.Errorf("%s: segment %q ends before it starts", test.name, segment.Text)
+			}
+		}
+		if strings.Join(text, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%s: text is %q, expected %q", test.name, text, test.expected)
+		}
+		for i := range starts {
+			if i < len(test.starts) && starts[i] != test.starts[i] {
+				t.Errorf("%s: segment %d starts at %v, expected %v", test.name, i, starts[i], test.starts[i])
+			}
+		}
+	}
+}
+
+func TestMatchWords(t *testing.T) {
+	tail := strings.Fields("the quick brown fox, jumps")
+	tests := []struct {
+		words    string
+		expected int
+	}{
+		{"", 0},
+		{"jumps over", 1},
+		{"Fox Jumps! over", 2},
+		{"the quick brown fox jumps", 5},
+		{"quick brown", 0},
+		{"the quick brown fox jumps over the lazy dog", 5},
+	}
+	for _, test := range tests {
+		if n := matchWords(tail, strings.Fields(test.words)); n != test.expected {
+			t.Errorf("matchWords(%q) = %d, expected %d", test.words, n, test.expected)
+		}
+	}
+}
+
+func TestQuietest(t *testing.T) {
+	data := make([]float32, SampleRate)
+	for i := range data {
+		if i < SampleRate/2 || i >= SampleRate/2+toSamples(100*time.Millisecond) {
+			data[i] = 0.5
+		}
+	}
+	pos := quietest(data, 0, len(data))
+	if pos < SampleRate/2 || pos >= SampleRate/2+toSamples(100*time.Millisecond) {
+		t.Errorf("quietest point is %v, expected within the silence at 0.5s", toDuration(pos))
+	}
+	if pos := quietest(data, 100, 110); pos != 110 {
+		t.Errorf("expected the end of a range shorter than a frame, got %d", pos)
+	}
+}
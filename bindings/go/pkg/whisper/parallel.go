@@ -0,0 +1,173 @@
+package whisper
+
+import (
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ParallelOptions configures TranscribeParallel. Zero values are replaced
+// by defaults.
+type ParallelOptions struct {
+	// Number of states processing shards concurrently, which defaults to
+	// one for every four threads set on the context, and at least two. The
+	// threads are divided between them.
+	States int
+
+	// Number of shards the audio is split into, at least States
+	Shards int
+
+	// Audio shared between consecutive shards, so that words on a
+	// boundary are heard in full by at least one of them
+	Overlap time.Duration
+
+	// How far either side of each split point to look for a quiet point
+	// to cut at
+	Search time.Duration
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultParallelThreads = 4 // Threads per state when States is not set
+	minParallelStates      = 2 // States when States is not set
+	defaultParallelOverlap = time.Second
+	defaultParallelSearch  = 10 * time.Second
+	minParallelShard       = 30 * time.Second // Shorter shards are merged
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// TranscribeParallel transcribes one recording by splitting it into shards at
+// quiet points and processing them concurrently on a pool of states which
+// share the model. Segments are merged with timestamps relative to the start
+// of the recording, and words repeated in the overlap between shards are
+// removed. Each segment has the language detected for its shard.
+func TranscribeParallel(ctx Context, data []float32, opts ParallelOptions) ([]Segment, error) {
+	parent, ok := ctx.(*context)
+	if !ok || parent.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+
+	// The offset and duration apply to the whole audio rather than to
+	// each shard
+	context := *parent
+	data, first := trimAudio(&context.params, data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	opts = opts.withDefaults(parent.params.Threads(), len(data))
+
+	// Divide the threads between states, and use token timestamps to trim
+	// the overlap. Each state processes shards which are not next to each
+	// other, so past text is not used as the prompt.
+	if threads := parent.params.Threads() / opts.States; threads > 1 {
+		context.params.SetThreads(threads)
+	} else {
+		context.params.SetThreads(1)
+	}
+	context.params.SetTokenTimestamps(true)
+	context.params.SetNoContext(true)
+
+	// Cut the shards at quiet points near equally spaced split points
+	cuts := make([]int, opts.Shards+1)
+	cuts[opts.Shards] = len(data)
+	search := toSamples(opts.Search)
+	for i := 1; i < opts.Shards; i++ {
+		target := i * len(data) / opts.Shards
+		cuts[i] = quietest(data, target-search, target+search)
+		if cuts[i] <= cuts[i-1] {
+			cuts[i] = target
+		}
+	}
+
+	// Process shards on a pool of states
+	results := make([][]Segment, opts.Shards)
+	errs := make([]error, opts.Shards)
+	shards := make(chan int, opts.Shards)
+	for i := 0; i < opts.Shards; i++ {
+		shards <- i
+	}
+	close(shards)
+
+	var wg sync.WaitGroup
+	overlap := toSamples(opts.Overlap)
+	for i := 0; i < opts.States; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state := context.NewState()
+			defer state.Close()
+			for shard := range shards {
+				start := cuts[shard]
+				if shard > 0 {
+					if start -= overlap; start < 0 {
+						start = 0
+					}
+				}
+				segments, err := context.Process(state, data[start:cuts[shard+1]])
+				if err != nil {
+					errs[shard] = err
+					continue
+				}
+				offset := toDuration(first + start)
+				for j := range segments {
+					segments[j] = shiftSegment(segments[j], offset)
+				}
+				results[shard] = segments
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Merge the shards in order
+	var result []Segment
+	for shard, segments := range results {
+		if errs[shard] != nil {
+			return nil, errs[shard]
+		}
+		segments = dedupe(result, segments)
+		for i := range segments {
+			segments[i].Num = len(result) + i
+		}
+		result = append(result, segments...)
+	}
+
+	// Return success
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (opts ParallelOptions) withDefaults(threads, samples int) ParallelOptions {
+	if opts.States <= 0 {
+		if opts.States = threads / defaultParallelThreads; opts.States < minParallelStates {
+			opts.States = minParallelStates
+		}
+	}
+	if opts.Shards < opts.States {
+		opts.Shards = opts.States
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = defaultParallelOverlap
+	}
+	if opts.Search <= 0 {
+		opts.Search = defaultParallelSearch
+	}
+
+	// Don't split into shards which are too short to be worthwhile
+	if limit := samples / toSamples(minParallelShard); opts.Shards > limit {
+		if opts.Shards = limit; opts.Shards < 1 {
+			opts.Shards = 1
+		}
+	}
+	if opts.States > opts.Shards {
+		opts.States = opts.Shards
+	}
+	return opts
+}
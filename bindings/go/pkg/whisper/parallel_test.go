@@ -0,0 +1,59 @@
+package whisper
+
+import (
+	"testing"
+	"time"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+func TestParallelOptions(t *testing.T) {
+	minute := toSamples(time.Minute)
+	tests := []struct {
+		name     string
+		opts     ParallelOptions
+		threads  int
+		samples  int
+		expected ParallelOptions
+	}{
+		{"defaults", ParallelOptions{}, 4, 10 * minute, ParallelOptions{States: 2, Shards: 2, Overlap: time.Second, Search: 10 * time.Second}},
+		{"threads", ParallelOptions{}, 16, 10 * minute, ParallelOptions{States: 4, Shards: 4, Overlap: time.Second, Search: 10 * time.Second}},
+		{"shards", ParallelOptions{States: 2, Shards: 8}, 4, 10 * minute, ParallelOptions{States: 2, Shards: 8, Overlap: time.Second, Search: 10 * time.Second}},
+		{"short", ParallelOptions{States: 4}, 4, minute, ParallelOptions{States: 2, Shards: 2, Overlap: time.Second, Search: 10 * time.Second}},
+		{"very short", ParallelOptions{States: 4}, 4, minute / 4, ParallelOptions{States: 1, Shards: 1, Overlap: time.Second, Search: 10 * time.Second}},
+		{"overlap", ParallelOptions{States: 2, Overlap: 3 * time.Second, Search: time.Second}, 4, 10 * minute, ParallelOptions{States: 2, Shards: 2, Overlap: 3 * time.Second, Search: time.Second}},
+	}
+	for _, test := range tests {
+		if opts := test.opts.withDefaults(test.threads, test.samples); opts != test.expected {
+			t.Errorf("%s: options are %+v, expected %+v", test.name, opts, test.expected)
+		}
+	}
+}
+
+func TestTrimAudio(t *testing.T) {
+	data := make([]float32, 10*SampleRate)
+	tests := []struct {
+		offset, duration time.Duration
+		start, length    int
+	}{
+		{0, 0, 0, len(data)},
+		{2 * time.Second, 0, 2 * SampleRate, 8 * SampleRate},
+		{0, 3 * time.Second, 0, 3 * SampleRate},
+		{2 * time.Second, 3 * time.Second, 2 * SampleRate, 3 * SampleRate},
+		{8 * time.Second, 5 * time.Second, 8 * SampleRate, 2 * SampleRate},
+		{20 * time.Second, 0, len(data), 0},
+	}
+	for _, test := range tests {
+		var params whisper.Params
+		params.SetOffset(int(test.offset.Milliseconds()))
+		params.SetDuration(int(test.duration.Milliseconds()))
+		result, start := trimAudio(&params, data)
+		if start != test.start || len(result) != test.length {
+			t.Errorf("offset %v and duration %v: got %d samples from %d, expected %d from %d", test.offset, test.duration, len(result), start, test.length, test.start)
+		}
+		if params.Offset() != 0 || params.Duration() != 0 {
+			t.Errorf("offset %v and duration %v: not cleared", test.offset, test.duration)
+		}
+	}
+}
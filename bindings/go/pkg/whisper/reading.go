@@ -0,0 +1,254 @@
+package whisper
+
+import (
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ReadingOptions configures ScoreReading. Zero values are replaced by
+// defaults.
+type ReadingOptions struct {
+	// A pause between words at least this long is a hesitation
+	Pause time.Duration
+
+	// Inserted words which are hesitations, such as "um", compared
+	// ignoring case and punctuation
+	Fillers []string
+}
+
+// WordStatus is the result of reading one word
+type WordStatus int
+
+// ReadingWord is a word of the reference passage, or a word inserted by the
+// reader, with the recognized word it was matched to
+type ReadingWord struct {
+	Status WordStatus
+
+	// The word of the reference passage, or empty when inserted
+	Reference string
+
+	// Position of the word in the reference passage, or the position of
+	// the next reference word when inserted
+	Index int
+
+	// The recognized word, or empty with zero timestamps when omitted
+	Word
+}
+
+// Hesitation is a pause or filler word while reading
+type Hesitation struct {
+	Start, End time.Duration
+
+	// Position of the reference word which follows the hesitation
+	Index int
+
+	// The filler word, or empty for a pause
+	Text string
+}
+
+// ReadingScore is the result of scoring a reading of a passage
+type ReadingScore struct {
+	// Reference words in order, with inserted words between them
+	Words []ReadingWord
+
+	// Number of words with each status
+	Correct, Substituted, Omitted, Inserted int
+
+	// Fraction of reference words read correctly
+	Accuracy float64
+
+	// Correct words per minute, from the start of the first recognized
+	// word to the end of the last
+	WordsPerMinute float64
+
+	// Pauses and filler words, in order
+	Hesitations []Hesitation
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	WORD_CORRECT WordStatus = iota
+	WORD_SUBSTITUTED
+	WORD_OMITTED
+	WORD_INSERTED
+)
+
+const (
+	defaultReadingPause = 700 * time.Millisecond
+
+	// Edit costs, with a substitution costing more than an omission or
+	// insertion alone so that alignments with more correct words win
+	costGap        = 2
+	costSubstitute = 3
+)
+
+var (
+	defaultReadingFillers = []string{"um", "umm", "uh", "uhh", "er", "erm", "ah", "hmm", "mm"}
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ScoreReading scores segments transcribed from a learner reading a passage
+// aloud. Recognized words are aligned to the words of the passage by edit
+// distance, ignoring case and punctuation. Token timestamps should be enabled
+// when processing, so that words and pauses have accurate times.
+func ScoreReading(reference string, segments []Segment, opts ReadingOptions) ReadingScore {
+	opts = opts.withDefaults()
+
+	ref := strings.Fields(reference)
+	var hyp []Word
+	for _, segment := range segments {
+		hyp = append(hyp, segment.Words()...)
+	}
+
+	// Edit distance between the normalized words, where cost[i][j] is the
+	// cost of aligning the first i reference and first j recognized words
+	a, b := make([]string, len(ref)), make([]string, len(hyp))
+	for i, word := range ref {
+		a[i] = normalizeWord(word)
+	}
+	for j, word := range hyp {
+		b[j] = normalizeWord(word.Text)
+	}
+	cost := make([][]int, len(a)+1)
+	for i := range cost {
+		cost[i] = make([]int, len(b)+1)
+		cost[i][0] = i * costGap
+	}
+	for j := range cost[0] {
+		cost[0][j] = j * costGap
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			sub := cost[i-1][j-1]
+			if a[i-1] != b[j-1] {
+				sub += costSubstitute
+			}
+			cost[i][j] = minInt(sub, minInt(cost[i-1][j]+costGap, cost[i][j-1]+costGap))
+		}
+	}
+
+	// Backtrace from the end, preferring matches and substitutions
+	var words []ReadingWord
+	for i, j := len(a), len(b); i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && a[i-1] == b[j-1] && cost[i][j] == cost[i-1][j-1]:
+			words = append(words, ReadingWord{Status: WORD_CORRECT, Reference: ref[i-1], Index: i - 1, Word: hyp[j-1]})
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && cost[i][j] == cost[i-1][j-1]+costSubstitute:
+			words = append(words, ReadingWord{Status: WORD_SUBSTITUTED, Reference: ref[i-1], Index: i - 1, Word: hyp[j-1]})
+			i, j = i-1, j-1
+		case i > 0 && cost[i][j] == cost[i-1][j]+costGap:
+			words = append(words, ReadingWord{Status: WORD_OMITTED, Reference: ref[i-1], Index: i - 1})
+			i--
+		default:
+			words = append(words, ReadingWord{Status: WORD_INSERTED, Index: i, Word: hyp[j-1]})
+			j--
+		}
+	}
+	for l, r := 0, len(words)-1; l < r; l, r = l+1, r-1 {
+		words[l], words[r] = words[r], words[l]
+	}
+
+	// Count each status
+	score := ReadingScore{Words: words}
+	for _, word := range words {
+		switch word.Status {
+		case WORD_CORRECT:
+			score.Correct++
+		case WORD_SUBSTITUTED:
+			score.Substituted++
+		case WORD_OMITTED:
+			score.Omitted++
+		case WORD_INSERTED:
+			score.Inserted++
+		}
+	}
+	if len(ref) > 0 {
+		score.Accuracy = float64(score.Correct) / float64(len(ref))
+	}
+	if len(hyp) > 0 {
+		if elapsed := hyp[len(hyp)-1].End - hyp[0].Start; elapsed > 0 {
+			score.WordsPerMinute = float64(score.Correct) / elapsed.Minutes()
+		}
+	}
+
+	// Find pauses between recognized words, and inserted filler words
+	var prev *ReadingWord
+	for i := range words {
+		word := &words[i]
+		if word.Status == WORD_OMITTED {
+			continue
+		}
+		if prev != nil && word.Start-prev.End >= opts.Pause {
+			score.Hesitations = append(score.Hesitations, Hesitation{
+				Start: prev.End,
+				End:   word.Start,
+				Index: word.Index,
+			})
+		}
+		if word.Status == WORD_INSERTED && opts.isFiller(word.Text) {
+			score.Hesitations = append(score.Hesitations, Hesitation{
+				Start: word.Start,
+				End:   word.End,
+				Index: word.Index,
+				Text:  word.Text,
+			})
+		}
+		prev = word
+	}
+
+	// Return the score
+	return score
+}
+
+func (s WordStatus) String() string {
+	switch s {
+	case WORD_CORRECT:
+		return "correct"
+	case WORD_SUBSTITUTED:
+		return "substituted"
+	case WORD_OMITTED:
+		return "omitted"
+	case WORD_INSERTED:
+		return "inserted"
+	default:
+		return "unknown"
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (opts ReadingOptions) withDefaults() ReadingOptions {
+	if opts.Pause <= 0 {
+		opts.Pause = defaultReadingPause
+	}
+	if opts.Fillers == nil {
+		opts.Fillers = defaultReadingFillers
+	}
+	return opts
+}
+
+func (opts ReadingOptions) isFiller(word string) bool {
+	word = normalizeWord(word)
+	for _, filler := range opts.Fillers {
+		if normalizeWord(filler) == word {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
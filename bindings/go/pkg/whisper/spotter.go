@@ -0,0 +1,309 @@
+package whisper
+
+import (
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type spotter struct {
+	context  *context
+	state    State
+	keywords []string
+	root     *trieNode
+
+	step, length int // In samples
+	threshold    float32
+	debounce     time.Duration
+
+	pcm     []float32             // Audio for the current window
+	start   int                   // Absolute position of the window, in samples
+	pending int                   // Samples pushed since the last processing
+	last    map[int]time.Duration // End of the last detection of each keyword
+}
+
+// Make sure spotter adheres to the interface
+var _ Spotter = (*spotter)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultSpotterStep        = time.Second
+	defaultSpotterLength      = 4 * time.Second
+	defaultSpotterSensitivity = 0.5
+	defaultSpotterDebounce    = time.Second
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newSpotter(parent *context, keywords []string) (*spotter, error) {
+	if parent.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+	spotter := new(spotter)
+	spotter.keywords = keywords
+	spotter.last = make(map[int]time.Duration)
+
+	// Copy the context so the parameters don't leak into it. Each window is
+	// decoded as a single segment without past text, with token timestamps
+	// to place the keywords.
+	context := *parent
+	context.params.SetSingleSegment(true)
+	context.params.SetNoContext(true)
+	context.params.SetTokenTimestamps(true)
+	context.params.SetPrintRealtime(false)
+	context.params.SetPrintProgress(false)
+
+	// Build the trie of tokens, as written, lower case and capitalized, at
+	// the start of the text and following a space
+	root := newTrieNode()
+	for i, keyword := range keywords {
+		keyword = strings.Join(strings.Fields(keyword), " ")
+		if keyword == "" {
+			continue
+		}
+		variants := []string{keyword, strings.ToLower(keyword), capitalize(strings.ToLower(keyword))}
+		for _, variant := range variants {
+			for _, text := range []string{" " + variant, variant} {
+				tokens, err := tokenize(context.model.ctx, text)
+				if err != nil {
+					return nil, err
+				}
+				root.insert(tokens, i)
+			}
+		}
+	}
+	if len(root.next) == 0 {
+		return nil, ErrNoKeywords
+	}
+	spotter.root = root
+	spotter.context = &context
+	spotter.state = context.NewState()
+
+	spotter.SetStep(defaultSpotterStep)
+	spotter.SetLength(defaultSpotterLength)
+	spotter.SetSensitivity(defaultSpotterSensitivity)
+	spotter.SetDebounce(defaultSpotterDebounce)
+
+	// Return success
+	return spotter, nil
+}
+
+func (spotter *spotter) Close() error {
+	var result error
+	if spotter.state != nil {
+		result = spotter.state.Close()
+	}
+
+	// Release resources
+	spotter.state = nil
+	spotter.pcm = nil
+
+	// Return any errors
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set how much new audio triggers processing
+func (spotter *spotter) SetStep(v time.Duration) {
+	if spotter.step = toSamples(v); spotter.step < 1 {
+		spotter.step = 1
+	}
+}
+
+// Set the length of the sliding window
+func (spotter *spotter) SetLength(v time.Duration) {
+	if spotter.length = toSamples(v); spotter.length < 1 {
+		spotter.length = 1
+	}
+}
+
+// Set the sensitivity from 0 to 1, where higher values report keywords
+// with lower confidence
+func (spotter *spotter) SetSensitivity(v float32) {
+	spotter.threshold = 1 - v
+}
+
+// Set the time after a detection during which the same keyword is not
+// reported again
+func (spotter *spotter) SetDebounce(v time.Duration) {
+	spotter.debounce = v
+}
+
+// Push mono audio data, and process the window each time a step of new
+// audio has arrived
+func (spotter *spotter) Push(data []float32) ([]Detection, error) {
+	spotter.pcm = append(spotter.pcm, data...)
+	spotter.pending += len(data)
+	if spotter.pending < spotter.step {
+		return nil, nil
+	} else {
+		spotter.pending = 0
+	}
+
+	// Keep only the audio for the window
+	if n := len(spotter.pcm) - spotter.length; n > 0 {
+		spotter.advance(n)
+	}
+	return spotter.process()
+}
+
+// Flush processes any audio which has not been processed, and resets the
+// spotter for a new stream
+func (spotter *spotter) Flush() ([]Detection, error) {
+	var result []Detection
+	var err error
+	if spotter.pending > 0 {
+		result, err = spotter.process()
+	}
+
+	// Reset
+	spotter.pcm = spotter.pcm[:0]
+	spotter.start, spotter.pending = 0, 0
+	spotter.last = make(map[int]time.Duration)
+
+	// Return any errors
+	return result, err
+}
+
+// Listen reads a raw audio stream in the given format until it ends, and
+// calls fn with each keyword detected. Returning an error from fn stops
+// listening.
+func (spotter *spotter) Listen(r io.Reader, format audio.Format, fn func(Detection) error) error {
+	reader, err := audio.NewReader(r, format)
+	if err != nil {
+		return err
+	}
+	buf := make([]float32, spotter.step)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			detections, err := spotter.Push(buf[:n])
+			if err != nil {
+				return err
+			}
+			if err := emitDetections(detections, fn); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Process the end of the stream
+	detections, err := spotter.Flush()
+	if err != nil {
+		return err
+	}
+	return emitDetections(detections, fn)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// process runs the model on the window and returns new detections
+func (spotter *spotter) process() ([]Detection, error) {
+	segments, err := spotter.context.Process(spotter.state, spotter.pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match keywords which start and end on word boundaries
+	var tokens []Token
+	for _, segment := range segments {
+		for _, token := range segment.Tokens {
+			if !token.IsSpecial() {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	var result []Detection
+	offset := toDuration(spotter.start)
+	for i := range tokens {
+		if i > 0 && !strings.HasPrefix(tokens[i].Text, " ") {
+			continue
+		}
+		node := spotter.root
+		for j := i; j < len(tokens) && node != nil; j++ {
+			if node = node.next[whisper.Token(tokens[j].Id)]; node == nil {
+				break
+			} else if j+1 < len(tokens) && continuesWord(tokens[j+1].Text) {
+				continue
+			}
+			for _, keyword := range node.ends {
+				if detection, ok := spotter.detect(keyword, tokens[i:j+1], offset); ok {
+					result = append(result, detection)
+				}
+			}
+		}
+	}
+
+	// Return success
+	return result, nil
+}
+
+// detect returns a detection of a keyword from its tokens, unless the
+// confidence is too low or the keyword was detected recently
+func (spotter *spotter) detect(keyword int, tokens []Token, offset time.Duration) (Detection, bool) {
+	var logp float64
+	for _, token := range tokens {
+		logp += math.Log(math.Max(float64(token.P), 1e-10))
+	}
+	detection := Detection{
+		Keyword:    spotter.keywords[keyword],
+		Index:      keyword,
+		Start:      tokens[0].Start + offset,
+		End:        tokens[len(tokens)-1].End + offset,
+		Confidence: float32(math.Exp(logp / float64(len(tokens)))),
+	}
+	if detection.Confidence < spotter.threshold {
+		return detection, false
+	}
+	if last, exists := spotter.last[keyword]; exists && detection.Start < last+spotter.debounce {
+		if detection.End > last {
+			spotter.last[keyword] = detection.End
+		}
+		return detection, false
+	}
+	spotter.last[keyword] = detection.End
+	return detection, true
+}
+
+// continuesWord returns true if a token continues the word before it
+func continuesWord(token string) bool {
+	r, _ := utf8.DecodeRuneInString(token)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// advance drops samples from the start of the window
+func (spotter *spotter) advance(n int) {
+	spotter.pcm = append(spotter.pcm[:0], spotter.pcm[n:]...)
+	spotter.start += n
+}
+
+// emitDetections calls fn with each detection
+func emitDetections(detections []Detection, fn func(Detection) error) error {
+	for _, detection := range detections {
+		if err := fn(detection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
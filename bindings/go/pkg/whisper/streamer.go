@@ -0,0 +1,248 @@
+package whisper
+
+import (
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type streamer struct {
+	context *context
+	state   State
+
+	step, length, keep int // In samples
+	overlap            int // Overlap set with SetKeep, before it is limited
+
+	pcm       []float32     // Audio for the current window
+	start     int           // Absolute position of the window, in samples
+	pending   int           // Samples pushed since the last processing
+	committed time.Duration // End of the final text
+	n         int           // Number of final segments
+	partial   []Segment     // Current partial hypothesis
+}
+
+// Make sure streamer adheres to the interface
+var _ Streamer = (*streamer)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Defaults, as used by examples/stream
+const (
+	defaultStreamStep   = 3 * time.Second
+	defaultStreamLength = 10 * time.Second
+	defaultStreamKeep   = 200 * time.Millisecond
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newStreamer(parent *context) *streamer {
+	streamer := new(streamer)
+
+	// Copy the context so the streaming parameters don't leak into it. Each
+	// window is decoded as a single segment without past text, and token
+	// timestamps are used to trim text already emitted from the overlap.
+	// The offset and duration don't apply to a stream, so each window is
+	// processed in full.
+	context := *parent
+	context.params.SetOffset(0)
+	context.params.SetDuration(0)
+	context.params.SetSingleSegment(true)
+	context.params.SetNoContext(true)
+	context.params.SetTokenTimestamps(true)
+	context.params.SetPrintRealtime(false)
+	context.params.SetPrintProgress(false)
+	streamer.context = &context
+	streamer.state = context.NewState()
+
+	streamer.SetStep(defaultStreamStep)
+	streamer.SetLength(defaultStreamLength)
+	streamer.SetKeep(defaultStreamKeep)
+
+	// Return success
+	return streamer
+}
+
+func (streamer *streamer) Close() error {
+	var result error
+	if streamer.state != nil {
+		result = streamer.state.Close()
+	}
+
+	// Release resources
+	streamer.state = nil
+	streamer.pcm = nil
+
+	// Return any errors
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set how much new audio triggers processing
+func (streamer *streamer) SetStep(v time.Duration) {
+	if streamer.step = toSamples(v); streamer.step < 1 {
+		streamer.step = 1
+	}
+	streamer.setKeep()
+}
+
+// Set the length of the sliding window
+func (streamer *streamer) SetLength(v time.Duration) {
+	if streamer.length = toSamples(v); streamer.length < 1 {
+		streamer.length = 1
+	}
+	streamer.setKeep()
+}
+
+// Set the overlap kept between windows, which is limited to the length of
+// the window less one step so that each window moves on
+func (streamer *streamer) SetKeep(v time.Duration) {
+	streamer.overlap = toSamples(v)
+	streamer.setKeep()
+}
+
+// Set the audio context size (0 = use default)
+func (streamer *streamer) SetAudioCtx(n uint) {
+	streamer.context.params.SetAudioCtx(int(n))
+}
+
+// Push mono audio data, and process the window each time a step of new
+// audio has arrived
+func (streamer *streamer) Push(data []float32) ([]Segment, []Segment, error) {
+	streamer.pcm = append(streamer.pcm, data...)
+	streamer.pending += len(data)
+	if streamer.pending < streamer.step {
+		return nil, streamer.partial, nil
+	} else {
+		streamer.pending = 0
+	}
+
+	// Commit each complete window, keeping some overlap for the next one
+	var final []Segment
+	for len(streamer.pcm) >= streamer.length {
+		if segments, err := streamer.process(streamer.pcm[:streamer.length]); err != nil {
+			return nil, nil, err
+		} else {
+			final = append(final, streamer.commit(segments)...)
+		}
+		streamer.advance(streamer.length - streamer.keep)
+	}
+
+	// Revise the partial hypothesis for the rest of the window
+	streamer.partial = nil
+	if len(streamer.pcm) > streamer.keep {
+		if segments, err := streamer.process(streamer.pcm); err != nil {
+			return final, nil, err
+		} else {
+			streamer.partial = streamer.trim(segments)
+		}
+	}
+
+	// Return success
+	return final, streamer.partial, nil
+}
+
+// Flush processes any remaining audio as final segments, and resets the
+// streamer for a new stream
+func (streamer *streamer) Flush() ([]Segment, error) {
+	var final []Segment
+	if len(streamer.pcm) > streamer.keep {
+		if segments, err := streamer.process(streamer.pcm); err != nil {
+			return nil, err
+		} else {
+			final = streamer.commit(segments)
+		}
+	}
+
+	// Reset
+	streamer.pcm = streamer.pcm[:0]
+	streamer.start, streamer.pending, streamer.n = 0, 0, 0
+	streamer.committed = 0
+	streamer.partial = nil
+
+	// Return success
+	return final, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// setKeep limits the overlap to the length of the window less one step
+func (streamer *streamer) setKeep() {
+	streamer.keep = streamer.overlap
+	if limit := streamer.length - streamer.step; streamer.keep > limit {
+		streamer.keep = limit
+	}
+	if streamer.keep < 0 {
+		streamer.keep = 0
+	}
+}
+
+// process runs the model on a window and returns segments with absolute
+// timestamps
+func (streamer *streamer) process(window []float32) ([]Segment, error) {
+	segments, err := streamer.context.Process(streamer.state, window)
+	if err != nil {
+		return nil, err
+	}
+	offset := toDuration(streamer.start)
+	for i := range segments {
+		segments[i] = shiftSegment(segments[i], offset)
+	}
+	return segments, nil
+}
+
+// commit marks segments as final, and advances the committed position
+func (streamer *streamer) commit(segments []Segment) []Segment {
+	result := streamer.trim(segments)
+	for i := range result {
+		result[i].Num = streamer.n
+		streamer.n++
+	}
+	if len(result) > 0 {
+		streamer.committed = result[len(result)-1].End
+	}
+	return result
+}
+
+// trim removes text from segments which was already committed from the
+// overlap with the previous window
+func (streamer *streamer) trim(segments []Segment) []Segment {
+	result := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.End <= streamer.committed {
+			continue
+		}
+		if segment.Start < streamer.committed {
+			var text strings.Builder
+			tokens := make([]Token, 0, len(segment.Tokens))
+			for _, token := range segment.Tokens {
+				if (token.Start+token.End)/2 < streamer.committed {
+					continue
+				}
+				if streamer.context.IsText(token) {
+					text.WriteString(token.Text)
+				}
+				tokens = append(tokens, token)
+			}
+			segment.Start = streamer.committed
+			segment.Text = strings.TrimSpace(text.String())
+			segment.Tokens = tokens
+		}
+		if segment.Text != "" {
+			result = append(result, segment)
+		}
+	}
+	return result
+}
+
+// advance drops samples from the start of the window
+func (streamer *streamer) advance(n int) {
+	streamer.pcm = append(streamer.pcm[:0], streamer.pcm[n:]...)
+	streamer.start += n
+}
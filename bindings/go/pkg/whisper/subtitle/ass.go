@@ -0,0 +1,310 @@
+package subtitle
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ASSEncoder writes Advanced SubStation Alpha (.ass) subtitles. Styles,
+// speakers and other settings must be set before the first call to Encode.
+type ASSEncoder struct {
+	w        io.Writer
+	header   bool
+	title    string
+	width    int
+	height   int
+	styles   []Style
+	speakers []string
+	karaoke  bool
+	speaker  int // Index of the current speaker, following speaker turns
+}
+
+// Style is a named style in the styles section of an ASS file
+type Style struct {
+	Name     string
+	Font     string
+	Size     int
+	Bold     bool
+	Italic   bool
+	Primary  color.NRGBA // Colour of the text
+	Karaoke  color.NRGBA // Colour of karaoke text before it is sung
+	Outline  color.NRGBA // Colour of the outline
+	Back     color.NRGBA // Colour of the shadow
+	Border   float64     // Width of the outline, in pixels
+	Shadow   float64     // Depth of the shadow, in pixels
+	Align    int         // Position as on a numeric keypad, such as 2 for bottom center
+	MarginL  int
+	MarginR  int
+	MarginV  int
+	Encoding int // Font character set, 1 for default
+}
+
+// Make sure ASSEncoder adheres to the interface
+var _ Encoder = (*ASSEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	assDefaultStyle = "Default"
+)
+
+var (
+	// Text colours for speaker styles, in order
+	assSpeakerColours = []color.NRGBA{
+		{0xFF, 0xFF, 0xFF, 0xFF}, // White
+		{0xFF, 0xFF, 0x00, 0xFF}, // Yellow
+		{0x00, 0xFF, 0xFF, 0xFF}, // Cyan
+		{0x00, 0xFF, 0x00, 0xFF}, // Green
+		{0xFF, 0x80, 0xFF, 0xFF}, // Pink
+		{0xFF, 0xA0, 0x40, 0xFF}, // Orange
+	}
+
+	// Escapes for override blocks and line breaks. A backslash in the text
+	// is followed by a word joiner, so that text such as \N or \h is not
+	// read as a code.
+	assEscape = strings.NewReplacer("\\", "\\\u2060", "{", "\\{", "}", "\\}", "\n", "\\N")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewASSEncoder returns an encoder which writes ASS subtitles to w, with a
+// default style for 1080p video
+func NewASSEncoder(w io.Writer) *ASSEncoder {
+	e := &ASSEncoder{w: w, width: 1920, height: 1080}
+	e.styles = []Style{DefaultStyle()}
+	return e
+}
+
+// DefaultStyle returns the style used for text without a speaker
+func DefaultStyle() Style {
+	return Style{
+		Name:     assDefaultStyle,
+		Font:     "Arial",
+		Size:     64,
+		Primary:  color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		Karaoke:  color.NRGBA{0x80, 0x80, 0x80, 0xFF},
+		Outline:  color.NRGBA{0x00, 0x00, 0x00, 0xFF},
+		Back:     color.NRGBA{0x00, 0x00, 0x00, 0x80},
+		Border:   3,
+		Shadow:   1,
+		Align:    2,
+		MarginL:  60,
+		MarginR:  60,
+		MarginV:  50,
+		Encoding: 1,
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the title in the script info section
+func (e *ASSEncoder) SetTitle(title string) {
+	e.title = title
+}
+
+// Set the video resolution which positions and sizes refer to
+func (e *ASSEncoder) SetResolution(width, height int) {
+	e.width, e.height = width, height
+}
+
+// Add a style, or replace the style with the same name
+func (e *ASSEncoder) AddStyle(style Style) {
+	for i := range e.styles {
+		if e.styles[i].Name == style.Name {
+			e.styles[i] = style
+			return
+		}
+	}
+	e.styles = append(e.styles, style)
+}
+
+// Set speaker labels, and add a style for each speaker in a different
+// colour. When set, Encode follows tinydiarize speaker turns through the
+// speakers in order.
+func (e *ASSEncoder) SetSpeakers(labels ...string) {
+	e.speakers = labels
+	for i, label := range labels {
+		style := DefaultStyle()
+		style.Name = styleName(label)
+		style.Primary = assSpeakerColours[i%len(assSpeakerColours)]
+		e.AddStyle(style)
+	}
+}
+
+// Set karaoke mode, where each token is highlighted in turn using token
+// timestamps
+func (e *ASSEncoder) SetKaraoke(v bool) {
+	e.karaoke = v
+}
+
+// Encode writes the header on the first call, and each segment with text
+// as a dialogue line. When speakers are set, speaker turns predicted by
+// tinydiarize move to the next speaker.
+func (e *ASSEncoder) Encode(segments []whisper.Segment) error {
+	var labels []string
+	if len(e.speakers) > 0 {
+		labels = make([]string, len(segments))
+		for i, segment := range segments {
+			labels[i] = e.speakers[e.speaker]
+			if segment.SpeakerTurnNext {
+				e.speaker = (e.speaker + 1) % len(e.speakers)
+			}
+		}
+	}
+	return e.EncodeSpeakers(segments, labels)
+}
+
+// EncodeSpeakers writes the header on the first call, and each segment
+// with text as a dialogue line spoken by the speaker with the same index,
+// such as a channel label. Speakers with a style of the same name use it,
+// and others use the default style.
+func (e *ASSEncoder) EncodeSpeakers(segments []whisper.Segment, speakers []string) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for i, segment := range segments {
+		if len(cueLines(segment.Text)) == 0 {
+			continue
+		}
+		var speaker string
+		if i < len(speakers) {
+			speaker = speakers[i]
+		}
+		if err := e.writeDialogue(segment, speaker); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the header if nothing has been encoded, so that the file is
+// valid even without dialogue
+func (e *ASSEncoder) Close() error {
+	return e.writeHeader()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *ASSEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	var b strings.Builder
+	b.WriteString("[Script Info]\n")
+	if title := strings.Join(strings.Fields(e.title), " "); title != "" {
+		fmt.Fprintf(&b, "Title: %s\n", title)
+	}
+	b.WriteString("ScriptType: v4.00+\n")
+	b.WriteString("WrapStyle: 0\n")
+	b.WriteString("ScaledBorderAndShadow: yes\n")
+	fmt.Fprintf(&b, "PlayResX: %d\nPlayResY: %d\n\n", e.width, e.height)
+
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	for _, style := range e.styles {
+		fmt.Fprintf(&b, "Style: %s,%s,%d,%s,%s,%s,%s,%d,%d,0,0,100,100,0,0,1,%g,%g,%d,%d,%d,%d,%d\n",
+			styleName(style.Name), strings.ReplaceAll(style.Font, ",", ""), style.Size,
+			assColour(style.Primary), assColour(style.Karaoke), assColour(style.Outline), assColour(style.Back),
+			assBool(style.Bold), assBool(style.Italic), style.Border, style.Shadow, style.Align,
+			style.MarginL, style.MarginR, style.MarginV, style.Encoding,
+		)
+	}
+
+	b.WriteString("\n[Events]\n")
+	b.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *ASSEncoder) writeDialogue(segment whisper.Segment, speaker string) error {
+	start, end := cueTimes(segment)
+	style := assDefaultStyle
+	for _, s := range e.styles {
+		if speaker != "" && s.Name == styleName(speaker) {
+			style = s.Name
+		}
+	}
+
+	var text string
+	if e.karaoke {
+		text = karaokeText(segment, start)
+	}
+	if text == "" {
+		text = assEscape.Replace(strings.Join(cueLines(segment.Text), "\n"))
+	}
+	_, err := fmt.Fprintf(e.w, "Dialogue: 0,%s,%s,%s,%s,0,0,0,,%s\n", assTimestamp(start), assTimestamp(end), style, styleName(speaker), text)
+	return err
+}
+
+// karaokeText returns the text of the segment with a \k tag before each
+// token, which lasts until the end of the token, or empty if the tokens
+// have no timestamps
+func karaokeText(segment whisper.Segment, start time.Duration) string {
+	var b strings.Builder
+	pos := start
+	for _, token := range segment.Tokens {
+		if token.IsSpecial() || token.Text == "" {
+			continue
+		}
+		if token.End < token.Start || token.End == 0 {
+			return ""
+		}
+		text := token.Text
+		if strings.HasPrefix(text, " ") {
+			if b.Len() > 0 {
+				b.WriteString(" ")
+			}
+			text = strings.TrimLeft(text, " ")
+		}
+		cs := int64(0)
+		if token.End > pos {
+			cs = (token.End - pos).Milliseconds() / 10
+			pos += time.Duration(cs) * 10 * time.Millisecond
+		}
+		fmt.Fprintf(&b, "{\\k%d}%s", cs, assEscape.Replace(text))
+	}
+	return b.String()
+}
+
+// assTimestamp returns a timestamp as h:mm:ss.cc
+func assTimestamp(t time.Duration) string {
+	if t < 0 {
+		t = 0
+	}
+	cs := t.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// assColour returns a colour as &HAABBGGRR, where alpha zero is opaque
+func assColour(c color.NRGBA) string {
+	return fmt.Sprintf("&H%02X%02X%02X%02X", 0xFF-c.A, c.B, c.G, c.R)
+}
+
+func assBool(v bool) int {
+	if v {
+		return -1
+	}
+	return 0
+}
+
+// styleName returns a name which can be used in a comma separated field
+func styleName(name string) string {
+	return strings.TrimSpace(strings.ReplaceAll(name, ",", " "))
+}
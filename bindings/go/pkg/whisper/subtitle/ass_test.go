@@ -0,0 +1,59 @@
+package subtitle
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestASSDialogue(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain", " Hello there", "Hello there"},
+		{"lines", "first\n\nsecond", "first\\Nsecond"},
+		{"braces", "a {\\b1} b", "a \\{\\\u2060b1\\} b"},
+		{"backslash", "C:\\New\\home", "C:\\\u2060New\\\u2060home"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewASSEncoder(&buf)
+		if err := e.Encode([]whisper.Segment{{Start: time.Second, End: 2 * time.Second, Text: test.text}}); err != nil {
+			t.Fatal(err)
+		}
+		expected := "Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,," + test.expected + "\n"
+		if !strings.HasSuffix(buf.String(), expected) {
+			t.Errorf("%s: expected %q at the end of %q", test.name, expected, buf.String())
+		}
+	}
+}
+
+func TestASSTimestamp(t *testing.T) {
+	tests := []struct {
+		t        time.Duration
+		expected string
+	}{
+		{-time.Second, "0:00:00.00"},
+		{1234 * time.Millisecond, "0:00:01.23"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03.00"},
+		{12 * time.Hour, "12:00:00.00"},
+	}
+	for _, test := range tests {
+		if v := assTimestamp(test.t); v != test.expected {
+			t.Errorf("assTimestamp(%v) = %q, expected %q", test.t, v, test.expected)
+		}
+	}
+}
+
+func TestASSColour(t *testing.T) {
+	if v := assColour(color.NRGBA{0x11, 0x22, 0x33, 0xFF}); v != "&H00332211" {
+		t.Errorf("unexpected colour %q", v)
+	}
+}
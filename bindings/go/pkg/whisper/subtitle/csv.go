@@ -0,0 +1,196 @@
+package subtitle
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// CSVEncoder writes segments as rows of comma or tab separated values, with
+// a header row. Times are in milliseconds, like the output of "main -ocsv".
+// The columns and speakers must be set before the first call to Encode.
+type CSVEncoder struct {
+	w        *csv.Writer
+	header   bool
+	columns  []Column
+	speakers []string
+	speaker  int // Index of the current speaker, following speaker turns
+}
+
+// Column is a column of CSV output
+type Column string
+
+// Make sure CSVEncoder adheres to the interface
+var _ Encoder = (*CSVEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	COLUMN_START      Column = "start"      // Start time in milliseconds
+	COLUMN_END        Column = "end"        // End time in milliseconds
+	COLUMN_TEXT       Column = "text"       // Text of the segment
+	COLUMN_CONFIDENCE Column = "confidence" // Geometric mean of the token probabilities, from 0 to 1
+	COLUMN_SPEAKER    Column = "speaker"    // Speaker label
+)
+
+var (
+	ErrUnknownColumn = errors.New("unknown column")
+)
+
+var (
+	defaultColumns = []Column{COLUMN_START, COLUMN_END, COLUMN_TEXT}
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewCSVEncoder returns an encoder which writes comma separated values to
+// w, with start, end and text columns
+func NewCSVEncoder(w io.Writer) *CSVEncoder {
+	return &CSVEncoder{w: csv.NewWriter(w), columns: defaultColumns}
+}
+
+// NewTSVEncoder returns an encoder which writes tab separated values to w,
+// with start, end and text columns
+func NewTSVEncoder(w io.Writer) *CSVEncoder {
+	e := NewCSVEncoder(w)
+	e.w.Comma = '\t'
+	return e
+}
+
+// ParseColumns returns columns from a comma separated list of names, such
+// as "start,end,speaker,text"
+func ParseColumns(v string) ([]Column, error) {
+	var result []Column
+	for _, name := range strings.Split(v, ",") {
+		switch column := Column(strings.ToLower(strings.TrimSpace(name))); column {
+		case COLUMN_START, COLUMN_END, COLUMN_TEXT, COLUMN_CONFIDENCE, COLUMN_SPEAKER:
+			result = append(result, column)
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the columns, in order
+func (e *CSVEncoder) SetColumns(columns ...Column) {
+	e.columns = columns
+}
+
+// Set speaker labels for the speaker column. When set, Encode follows
+// tinydiarize speaker turns through the speakers in order.
+func (e *CSVEncoder) SetSpeakers(labels ...string) {
+	e.speakers = labels
+}
+
+// Encode writes the header on the first call, and a row for each segment
+// with text
+func (e *CSVEncoder) Encode(segments []whisper.Segment) error {
+	var labels []string
+	if len(e.speakers) > 0 {
+		labels = make([]string, len(segments))
+		for i, segment := range segments {
+			labels[i] = e.speakers[e.speaker]
+			if segment.SpeakerTurnNext {
+				e.speaker = (e.speaker + 1) % len(e.speakers)
+			}
+		}
+	}
+	return e.EncodeSpeakers(segments, labels)
+}
+
+// EncodeSpeakers writes the header on the first call, and a row for each
+// segment with text, spoken by the speaker with the same index
+func (e *CSVEncoder) EncodeSpeakers(segments []whisper.Segment, speakers []string) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for i, segment := range segments {
+		lines := cueLines(segment.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		start, end := cueTimes(segment)
+		row := make([]string, len(e.columns))
+		for j, column := range e.columns {
+			switch column {
+			case COLUMN_START:
+				row[j] = fmt.Sprint(start.Milliseconds())
+			case COLUMN_END:
+				row[j] = fmt.Sprint(end.Milliseconds())
+			case COLUMN_TEXT:
+				row[j] = strings.Join(lines, " ")
+			case COLUMN_CONFIDENCE:
+				if confidence, ok := segmentConfidence(segment); ok {
+					row[j] = fmt.Sprintf("%.3f", confidence)
+				}
+			case COLUMN_SPEAKER:
+				if i < len(speakers) {
+					row[j] = speakers[i]
+				}
+			}
+		}
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	// Flush the rows
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// Close writes the header if nothing has been encoded
+func (e *CSVEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *CSVEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = string(column)
+	}
+	return e.w.Write(row)
+}
+
+// segmentConfidence returns the geometric mean of the probabilities of the
+// text tokens of a segment
+func segmentConfidence(segment whisper.Segment) (float64, bool) {
+	var logp float64
+	var n int
+	for _, token := range segment.Tokens {
+		if !token.IsSpecial() {
+			logp += math.Log(math.Max(float64(token.P), 1e-10))
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return math.Exp(logp / float64(n)), true
+}
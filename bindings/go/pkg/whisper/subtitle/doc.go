@@ -0,0 +1,6 @@
+/*
+Package subtitle encodes segments from the whisper package as subtitle files,
+such as SRT and WebVTT, as broadcast captions in SCC and TTML, as CSV
+and TSV tables, as LRC synced lyrics and as an HTML report for review
+*/
+package subtitle
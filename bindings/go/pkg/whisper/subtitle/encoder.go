@@ -0,0 +1,140 @@
+package subtitle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Encoder writes segments as subtitle cues
+type Encoder interface {
+	// Encode writes segments as cues. It may be called more than once, and
+	// cues are numbered across calls.
+	Encode([]whisper.Segment) error
+
+	// Close writes anything which follows the last cue. It does not close
+	// the underlying writer.
+	Close() error
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported subtitle format")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewEncoder returns an encoder for a subtitle format by name, which is the
+// usual file extension (srt, vtt, ass, scc, ttml, csv, tsv, lrc or html)
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "srt":
+		return NewSRTEncoder(w), nil
+	case "vtt", "webvtt":
+		return NewVTTEncoder(w), nil
+	case "ass":
+		return NewASSEncoder(w), nil
+	case "scc":
+		return NewSCCEncoder(w), nil
+	case "ttml", "dfxp":
+		return NewTTMLEncoder(w), nil
+	case "csv":
+		return NewCSVEncoder(w), nil
+	case "tsv":
+		return NewTSVEncoder(w), nil
+	case "lrc":
+		return NewLRCEncoder(w), nil
+	case "html":
+		return NewHTMLEncoder(w), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// formatTimestamp returns a timestamp as hh:mm:ss followed by the separator
+// and milliseconds
+func formatTimestamp(t time.Duration, sep byte) string {
+	if t < 0 {
+		t = 0
+	}
+	ms := t.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cueLines returns the lines of text for a cue, without blank lines which
+// would end the cue early
+func cueLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// wrapText breaks a line of text at spaces into lines of at most length
+// characters. Words longer than a line are broken across lines.
+func wrapText(text string, length int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		if len(line) > 0 && len(line)+1+len(runes) > length {
+			lines, line = append(lines, string(line)), line[:0]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		for len(line)+len(runes) > length {
+			n := length - len(line)
+			lines, line = append(lines, string(append(line, runes[:n]...))), line[:0]
+			runes = runes[n:]
+		}
+		line = append(line, runes...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// lineWords splits words between the lines of a cue in order, by the
+// number of characters on each line. The last line takes any words left.
+func lineWords(words []whisper.Word, lines []string) [][]whisper.Word {
+	result := make([][]whisper.Word, len(lines))
+	i := 0
+	for j, line := range lines {
+		n := utf8.RuneCountInString(strings.ReplaceAll(line, " ", ""))
+		for i < len(words) && (n > 0 || j == len(lines)-1) {
+			n -= utf8.RuneCountInString(words[i].Text)
+			result[j] = append(result[j], words[i])
+			i++
+		}
+	}
+	return result
+}
+
+// cueTimes returns the start and end of a segment, with the end never
+// before the start
+func cueTimes(segment whisper.Segment) (time.Duration, time.Duration) {
+	if segment.End < segment.Start {
+		return segment.Start, segment.Start
+	}
+	return segment.Start, segment.End
+}
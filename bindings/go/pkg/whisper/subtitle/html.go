@@ -0,0 +1,284 @@
+package subtitle
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Packages
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// HTMLEncoder writes a self-contained HTML report of a transcript for
+// review. The audio is embedded in a player, clicking a word seeks to it,
+// tokens are shaded by probability, and spans of low confidence are listed
+// at the end. The title, audio and threshold must be set before the first
+// call to Encode.
+type HTMLEncoder struct {
+	w         io.Writer
+	header    bool
+	closed    bool
+	title     string
+	audio     []float32
+	threshold float32
+	spans     []htmlSpan
+}
+
+// htmlSpan is a run of words with a token below the threshold
+type htmlSpan struct {
+	start, end time.Duration
+	text       []string
+	p          float32 // Lowest token probability
+}
+
+// Make sure HTMLEncoder adheres to the interface
+var _ Encoder = (*HTMLEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultHTMLThreshold = 0.5
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 0 auto; padding: 0 1em 2em; line-height: 1.6; }
+header { position: sticky; top: 0; background: #fff; padding: 1em 0; border-bottom: 1px solid #ddd; }
+audio { width: 100%%; }
+.segment { margin: 0.5em 0; }
+.time { color: #888; font-family: monospace; margin-right: 0.5em; cursor: pointer; }
+.word { cursor: pointer; border-radius: 2px; }
+.word:hover { outline: 1px solid #888; }
+.word.current { outline: 2px solid #06c; }
+.low a { cursor: pointer; color: #06c; font-family: monospace; margin-right: 0.5em; }
+</style>
+</head>
+<body>
+<header>
+<h1>%s</h1>
+`
+
+const htmlScript = `<script>
+(function() {
+	var player = document.getElementById("player");
+	var words = document.querySelectorAll(".word");
+	var current = null;
+	document.addEventListener("click", function(e) {
+		var target = e.target.closest("[data-start]");
+		if (target && player) {
+			player.currentTime = parseFloat(target.dataset.start);
+			player.play();
+			e.preventDefault();
+		}
+	});
+	if (player) {
+		player.addEventListener("timeupdate", function() {
+			var t = player.currentTime, next = null;
+			for (var i = 0; i < words.length; i++) {
+				if (parseFloat(words[i].dataset.start) <= t) {
+					next = words[i];
+				} else {
+					break;
+				}
+			}
+			if (next !== current) {
+				if (current) current.classList.remove("current");
+				if (next) next.classList.add("current");
+				current = next;
+			}
+		});
+	}
+})();
+</script>
+`
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewHTMLEncoder returns an encoder which writes an HTML report to w, where
+// tokens with a probability below 0.5 are listed for review
+func NewHTMLEncoder(w io.Writer) *HTMLEncoder {
+	return &HTMLEncoder{w: w, title: "Transcript", threshold: defaultHTMLThreshold}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the title of the report
+func (e *HTMLEncoder) SetTitle(title string) {
+	e.title = title
+}
+
+// Set 16 kHz mono audio which is embedded in the report, or nil for none
+func (e *HTMLEncoder) SetAudio(data []float32) {
+	e.audio = data
+}
+
+// Set the token probability, from 0 to 1, below which words are listed for
+// review
+func (e *HTMLEncoder) SetThreshold(v float32) {
+	e.threshold = v
+}
+
+// Encode writes the header and audio player on the first call, and each
+// segment with text as a paragraph of words
+func (e *HTMLEncoder) Encode(segments []whisper.Segment) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if len(cueLines(segment.Text)) == 0 {
+			continue
+		}
+		if err := e.writeSegment(segment); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the list of low confidence spans and the end of the report
+func (e *HTMLEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var b strings.Builder
+	b.WriteString("</main>\n<section class=\"low\">\n")
+	fmt.Fprintf(&b, "<h2>Low confidence (%d)</h2>\n", len(e.spans))
+	if len(e.spans) > 0 {
+		b.WriteString("<ol>\n")
+		for _, span := range e.spans {
+			fmt.Fprintf(&b, "<li><a data-start=\"%.3f\">%s-%s</a>%s <small>(p=%.2f)</small></li>\n",
+				span.start.Seconds(), lrcTimestamp(span.start), lrcTimestamp(span.end), html.EscapeString(strings.Join(span.text, " ")), span.p)
+		}
+		b.WriteString("</ol>\n")
+	}
+	b.WriteString("</section>\n")
+	b.WriteString(htmlScript)
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *HTMLEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	title := html.EscapeString(e.title)
+	if _, err := fmt.Fprintf(e.w, htmlHeader, title, title); err != nil {
+		return err
+	}
+
+	// Embed the audio as a WAV data URL
+	if e.audio != nil {
+		if _, err := io.WriteString(e.w, `<audio id="player" controls preload="auto" src="data:audio/wav;base64,`); err != nil {
+			return err
+		}
+		enc := base64.NewEncoder(base64.StdEncoding, e.w)
+		if err := audio.EncodeWAV(enc, e.audio); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(e.w, "\"></audio>\n"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(e.w, "</header>\n<main>\n")
+	return err
+}
+
+func (e *HTMLEncoder) writeSegment(segment whisper.Segment) error {
+	start, _ := cueTimes(segment)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p class=\"segment\"><span class=\"time\" data-start=\"%.3f\">%s</span>", start.Seconds(), lrcTimestamp(start))
+	var span *htmlSpan
+	for i, word := range segmentWords(segment) {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "<span class=\"word\" data-start=\"%.3f\">", word.Start.Seconds())
+
+		// Shade each token from red to green by probability. A word with
+		// characters split across tokens is shaded as a whole, and a word
+		// without tokens is not shaded.
+		p, split := float32(1), false
+		for _, token := range word.Tokens {
+			if token.P < p {
+				p = token.P
+			}
+			split = split || !utf8.ValidString(token.Text)
+		}
+		switch {
+		case len(word.Tokens) == 0:
+			b.WriteString(html.EscapeString(word.Text))
+		case split:
+			fmt.Fprintf(&b, "<span style=\"background:%s\" title=\"p=%.2f\">%s</span>", htmlShade(p), p, html.EscapeString(word.Text))
+		default:
+			for j, token := range word.Tokens {
+				text := token.Text
+				if j == 0 {
+					text = strings.TrimLeft(text, " ")
+				}
+				fmt.Fprintf(&b, "<span style=\"background:%s\" title=\"p=%.2f\">%s</span>", htmlShade(token.P), token.P, html.EscapeString(text))
+			}
+		}
+		b.WriteString("</span>")
+
+		// Collect consecutive words of low confidence
+		if p < e.threshold {
+			if span == nil {
+				e.spans = append(e.spans, htmlSpan{start: word.Start, p: p})
+				span = &e.spans[len(e.spans)-1]
+			}
+			span.end = word.End
+			span.text = append(span.text, word.Text)
+			if p < span.p {
+				span.p = p
+			}
+		} else {
+			span = nil
+		}
+	}
+	b.WriteString("</p>\n")
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// htmlShade returns a background colour for a probability, from red for
+// zero through yellow to green for one
+func htmlShade(p float32) string {
+	if p < 0 {
+		p = 0
+	} else if p > 1 {
+		p = 1
+	}
+	return fmt.Sprintf("hsl(%d,80%%,%d%%)", int(p*120), 75+int(p*15))
+}
@@ -0,0 +1,132 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// LRCEncoder writes synced lyrics (.lrc), with a line for each segment. In
+// enhanced mode each word is preceded by its start time. Tags and the mode
+// must be set before the first call to Encode.
+type LRCEncoder struct {
+	w        io.Writer
+	header   bool
+	tags     [][2]string
+	enhanced bool
+}
+
+// Make sure LRCEncoder adheres to the interface
+var _ Encoder = (*LRCEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewLRCEncoder returns an encoder which writes synced lyrics to w
+func NewLRCEncoder(w io.Writer) *LRCEncoder {
+	return &LRCEncoder{w: w, tags: [][2]string{{"by", "whisper.cpp"}}}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set an ID tag written at the top of the file, such as "ti" for the title
+// or "ar" for the artist, replacing any tag with the same name
+func (e *LRCEncoder) SetTag(name, value string) {
+	name = strings.TrimSpace(name)
+	value = strings.Join(strings.Fields(value), " ")
+	for i := range e.tags {
+		if e.tags[i][0] == name {
+			e.tags[i][1] = value
+			return
+		}
+	}
+	e.tags = append(e.tags, [2]string{name, value})
+}
+
+// Set enhanced mode, where each word is tagged with its start time and
+// each line ends with the end time of the last word. Segments without
+// token timestamps have their words spread evenly over the segment.
+func (e *LRCEncoder) SetEnhanced(v bool) {
+	e.enhanced = v
+}
+
+// Encode writes the tags on the first call, and a line for each segment
+// with text
+func (e *LRCEncoder) Encode(segments []whisper.Segment) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		lines := cueLines(segment.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		start, _ := cueTimes(segment)
+		text := strings.Join(lines, " ")
+		if e.enhanced {
+			text = enhancedText(segment)
+		}
+		if _, err := fmt.Fprintf(e.w, "[%s]%s\n", lrcTimestamp(start), text); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the tags if nothing has been encoded
+func (e *LRCEncoder) Close() error {
+	return e.writeHeader()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *LRCEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	var b strings.Builder
+	for _, tag := range e.tags {
+		if tag[0] != "" {
+			fmt.Fprintf(&b, "[%s:%s]\n", tag[0], tag[1])
+		}
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// enhancedText returns the words of a segment each preceded by a start
+// time, and followed by the end time of the last word
+func enhancedText(segment whisper.Segment) string {
+	words := segmentWords(segment)
+	parts := make([]string, 0, len(words)+1)
+	for _, word := range words {
+		parts = append(parts, "<"+lrcTimestamp(word.Start)+">"+word.Text)
+	}
+	if len(words) > 0 {
+		parts = append(parts, "<"+lrcTimestamp(words[len(words)-1].End)+">")
+	}
+	return strings.Join(parts, " ")
+}
+
+// lrcTimestamp returns a timestamp as mm:ss.xx, with minutes over 99 when
+// needed
+func lrcTimestamp(t time.Duration) string {
+	if t < 0 {
+		t = 0
+	}
+	cs := t.Milliseconds() / 10
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
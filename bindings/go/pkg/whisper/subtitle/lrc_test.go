@@ -0,0 +1,25 @@
+package subtitle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRCTimestamp(t *testing.T) {
+	tests := []struct {
+		t        time.Duration
+		expected string
+	}{
+		{-time.Second, "00:00.00"},
+		{0, "00:00.00"},
+		{1234 * time.Millisecond, "00:01.23"},
+		{59*time.Second + 999*time.Millisecond, "00:59.99"},
+		{time.Minute, "01:00.00"},
+		{100 * time.Minute, "100:00.00"},
+	}
+	for _, test := range tests {
+		if v := lrcTimestamp(test.t); v != test.expected {
+			t.Errorf("lrcTimestamp(%v) = %q, expected %q", test.t, v, test.expected)
+		}
+	}
+}
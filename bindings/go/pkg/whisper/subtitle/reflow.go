@@ -0,0 +1,350 @@
+package subtitle
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ReflowOptions are the rules for re-cutting segments into subtitle cues.
+// Zero values are replaced by defaults, which follow common broadcast
+// guidelines for adult content.
+type ReflowOptions struct {
+	// Maximum characters on each line, and lines in each cue
+	MaxLineLength int
+	MaxLines      int
+
+	// Maximum reading speed in characters per second, including spaces.
+	// Cues are extended to meet it where the gap to the next cue allows.
+	MaxCPS float64
+
+	// Minimum and maximum time each cue is shown
+	MinDuration time.Duration
+	MaxDuration time.Duration
+
+	// Minimum time between consecutive cues
+	MinGap time.Duration
+
+	// Pause between words which always starts a new cue
+	Pause time.Duration
+
+	// If set, cue times are snapped to frames of video at this rate, such
+	// as 25 or 29.97
+	FrameRate float64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultMaxLineLength = 42
+	defaultMaxLines      = 2
+	defaultMaxCPS        = 20
+	defaultMinDuration   = 833 * time.Millisecond
+	defaultMaxDuration   = 7 * time.Second
+	defaultMinGap        = 83 * time.Millisecond
+	defaultPause         = 1500 * time.Millisecond
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Reflow re-cuts segments into subtitle cues using word timestamps, so that
+// each cue fits the line length, reading speed and duration rules. Lines in
+// the text of each cue are separated by newlines. Segments without token
+// timestamps have their words spread evenly over the segment. Cues are never
+// merged across a speaker turn, and each cue has the language of the segment
+// it starts in.
+func Reflow(segments []whisper.Segment, opts ReflowOptions) []whisper.Segment {
+	opts = opts.withDefaults()
+
+	// Keep the segment each word is from, and whether a speaker turn
+	// follows it
+	var words []whisper.Word
+	var sources []int
+	var turns []bool
+	for i, segment := range segments {
+		n := len(words)
+		words = append(words, segmentWords(segment)...)
+		for j := n; j < len(words); j++ {
+			sources = append(sources, i)
+			turns = append(turns, segment.SpeakerTurnNext && j == len(words)-1)
+		}
+	}
+
+	// Cut cues where the text no longer fits, the cue would be too long, at
+	// a long pause, or at a speaker turn. Prefer to cut after the end of a
+	// sentence or clause once a cue is half full.
+	var cuts []int
+	maxChars := opts.MaxLineLength * opts.MaxLines
+	for i := 0; i < len(words); {
+		j, best := i+1, -1
+		for ; j < len(words); j++ {
+			if turns[j-1] || words[j].Start-words[j-1].End >= opts.Pause {
+				break
+			}
+			if words[j].End-words[i].Start > opts.MaxDuration || wrapLines(words[i:j+1], opts.MaxLineLength, opts.MaxLines+1) > opts.MaxLines {
+				if best > i && textLength(words[i:best]) >= maxChars/2 {
+					j = best
+				}
+				break
+			}
+			if endsClause(words[j-1].Text) {
+				best = j
+			}
+		}
+		cuts = append(cuts, i)
+		i = j
+	}
+
+	// Make a segment for each cue, with a balanced line break
+	result := make([]whisper.Segment, 0, len(cuts))
+	for i, start := range cuts {
+		end := len(words)
+		if i+1 < len(cuts) {
+			end = cuts[i+1]
+		}
+		cue := words[start:end]
+		segment := whisper.Segment{
+			Num:             i,
+			Start:           cue[0].Start,
+			End:             cue[len(cue)-1].End,
+			Text:            breakLines(cue, opts.MaxLineLength, opts.MaxLines),
+			Language:        segments[sources[start]].Language,
+			SpeakerTurnNext: turns[end-1],
+		}
+		for _, word := range cue {
+			segment.Tokens = append(segment.Tokens, word.Tokens...)
+		}
+		result = append(result, segment)
+	}
+
+	// Extend cues for reading speed and minimum duration, without running
+	// into the next cue, and keep gaps between cues
+	for i := range result {
+		cue := &result[i]
+		limit := time.Duration(math.MaxInt64)
+		if i+1 < len(result) {
+			limit = result[i+1].Start - opts.MinGap
+		}
+		need := time.Duration(float64(utf8.RuneCountInString(cue.Text)) / opts.MaxCPS * float64(time.Second))
+		if need < opts.MinDuration {
+			need = opts.MinDuration
+		}
+		if need > opts.MaxDuration {
+			need = opts.MaxDuration
+		}
+		if end := cue.Start + need; end > cue.End {
+			cue.End = end
+		}
+		if cue.End > limit {
+			cue.End = limit
+		}
+		if cue.End > cue.Start+opts.MaxDuration {
+			cue.End = cue.Start + opts.MaxDuration
+		}
+		if cue.End < cue.Start {
+			cue.End = cue.Start
+		}
+	}
+
+	// Snap to video frames, keeping at least the minimum gap
+	if opts.FrameRate > 0 {
+		gap := toFrame(opts.MinGap, opts.FrameRate, math.Ceil)
+		for i := range result {
+			result[i].Start = toFrame(result[i].Start, opts.FrameRate, math.Round)
+			result[i].End = toFrame(result[i].End, opts.FrameRate, math.Round)
+			if i > 0 && result[i-1].End > result[i].Start-gap {
+				result[i-1].End = result[i].Start - gap
+			}
+		}
+		for i := range result {
+			if result[i].End < result[i].Start {
+				result[i].End = result[i].Start
+			}
+		}
+	}
+
+	// Return the cues
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (opts ReflowOptions) withDefaults() ReflowOptions {
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = defaultMaxLineLength
+	}
+	if opts.MaxLines <= 0 {
+		opts.MaxLines = defaultMaxLines
+	}
+	if opts.MaxCPS <= 0 {
+		opts.MaxCPS = defaultMaxCPS
+	}
+	if opts.MinDuration <= 0 {
+		opts.MinDuration = defaultMinDuration
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = defaultMaxDuration
+	}
+	if opts.MaxDuration < opts.MinDuration {
+		opts.MaxDuration = opts.MinDuration
+	}
+	if opts.MinGap < 0 {
+		opts.MinGap = 0
+	} else if opts.MinGap == 0 {
+		opts.MinGap = defaultMinGap
+	}
+	if opts.Pause <= 0 {
+		opts.Pause = defaultPause
+	}
+	return opts
+}
+
+// segmentWords returns the words of a segment. When the tokens have no
+// usable timestamps, words are spread over the segment in proportion to
+// their length.
+func segmentWords(segment whisper.Segment) []whisper.Word {
+	result, timed := timedWords(segment)
+	if timed {
+		return result
+	}
+
+	// Spread words over the segment
+	if len(result) == 0 {
+		for _, text := range strings.Fields(segment.Text) {
+			result = append(result, whisper.Word{Text: text})
+		}
+	}
+	total := 0
+	for _, w := range result {
+		total += utf8.RuneCountInString(w.Text) + 1
+	}
+	duration, pos := segment.End-segment.Start, 0
+	for i := range result {
+		n := utf8.RuneCountInString(result[i].Text) + 1
+		result[i].Start = segment.Start + duration*time.Duration(pos)/time.Duration(total)
+		result[i].End = segment.Start + duration*time.Duration(pos+n)/time.Duration(total)
+		pos += n
+	}
+	return result
+}
+
+// timedWords returns the words of a segment, and true when their token
+// timestamps are usable, which needs token timestamps to be enabled
+func timedWords(segment whisper.Segment) ([]whisper.Word, bool) {
+	result := segment.Words()
+
+	// Check the timestamps are within the segment
+	timed := len(result) > 0 && result[len(result)-1].End > 0
+	for _, w := range result {
+		if w.End < w.Start || w.Start < segment.Start-time.Second || w.End > segment.End+time.Second {
+			timed = false
+		}
+	}
+	return result, timed
+}
+
+// wrapLines returns the number of lines needed to fit words into lines of
+// the given length, stopping at limit
+func wrapLines(words []whisper.Word, length, limit int) int {
+	lines, n := 1, 0
+	for _, w := range words {
+		size := utf8.RuneCountInString(w.Text)
+		switch {
+		case n == 0:
+			n = size
+		case n+1+size <= length:
+			n += 1 + size
+		default:
+			if lines++; lines >= limit {
+				return lines
+			}
+			n = size
+		}
+	}
+	return lines
+}
+
+// breakLines returns the text of words broken into at most maxLines lines,
+// choosing break points which balance the line lengths and preferring to
+// break after punctuation
+func breakLines(words []whisper.Word, length, maxLines int) string {
+	text := make([]string, len(words))
+	for i, w := range words {
+		text[i] = w.Text
+	}
+	if maxLines < 2 || textLength(words) <= length {
+		return strings.Join(text, " ")
+	}
+
+	// Break into two balanced lines where the text allows
+	if maxLines == 2 || wrapLines(words, length, 3) <= 2 {
+		best, score := 0, math.Inf(1)
+		for i := 1; i < len(words); i++ {
+			a, b := textLength(words[:i]), textLength(words[i:])
+			cost := math.Abs(float64(a - b))
+			if a > length || b > length {
+				cost += 1000
+			}
+			if endsClause(words[i-1].Text) {
+				cost -= float64(length) / 4
+			}
+			if cost < score {
+				best, score = i, cost
+			}
+		}
+		if best > 0 {
+			return strings.Join(text[:best], " ") + "\n" + strings.Join(text[best:], " ")
+		}
+		return strings.Join(text, " ")
+	}
+
+	// Otherwise fill lines in order
+	var lines []string
+	var line []string
+	n := 0
+	for i, w := range text {
+		size := utf8.RuneCountInString(w)
+		if n > 0 && n+1+size > length && len(lines) < maxLines-1 {
+			lines = append(lines, strings.Join(line, " "))
+			line, n = nil, 0
+		}
+		if n > 0 {
+			n++
+		}
+		line, n = append(line, text[i]), n+size
+	}
+	return strings.Join(append(lines, strings.Join(line, " ")), "\n")
+}
+
+// textLength returns the number of characters in words joined by spaces
+func textLength(words []whisper.Word) int {
+	n := 0
+	for i, w := range words {
+		if i > 0 {
+			n++
+		}
+		n += utf8.RuneCountInString(w.Text)
+	}
+	return n
+}
+
+// endsClause returns true if a word ends a sentence or clause
+func endsClause(text string) bool {
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, ",") || strings.HasSuffix(text, "?") ||
+		strings.HasSuffix(text, "!") || strings.HasSuffix(text, ";") || strings.HasSuffix(text, ":")
+}
+
+// toFrame rounds a time to a whole number of frames at the given rate
+func toFrame(t time.Duration, rate float64, round func(float64) float64) time.Duration {
+	return time.Duration(round(t.Seconds()*rate) / rate * float64(time.Second))
+}
@@ -0,0 +1,120 @@
+package subtitle
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestReflow(t *testing.T) {
+	s := time.Second
+	tests := []struct {
+		name     string
+		segments []whisper.Segment
+		expected []whisper.Segment
+	}{
+		{
+			"joined",
+			[]whisper.Segment{
+				{Start: 0, End: 1 * s, Text: "Hello there."},
+				{Start: 1 * s, End: 2 * s, Text: "How are you?"},
+			},
+			[]whisper.Segment{
+				{Start: 0, End: 2 * s, Text: "Hello there. How are you?"},
+			},
+		},
+		{
+			"pause",
+			[]whisper.Segment{
+				{Start: 0, End: 1 * s, Text: "Hello there."},
+				{Start: 5 * s, End: 6 * s, Text: "How are you?"},
+			},
+			[]whisper.Segment{
+				{Start: 0, End: 1 * s, Text: "Hello there."},
+				{Start: 5 * s, End: 6 * s, Text: "How are you?", Num: 1},
+			},
+		},
+		{
+			"minimum duration",
+			[]whisper.Segment{
+				{Start: 0, End: 200 * time.Millisecond, Text: "Hi"},
+				{Start: 2 * s, End: 2200 * time.Millisecond, Text: "Bye"},
+			},
+			[]whisper.Segment{
+				{Start: 0, End: defaultMinDuration, Text: "Hi"},
+				{Start: 2 * s, End: 2*s + defaultMinDuration, Text: "Bye", Num: 1},
+			},
+		},
+		{
+			"speaker turn",
+			[]whisper.Segment{
+				{Start: 0, End: 1 * s, Text: "Hello there.", SpeakerTurnNext: true, Language: "en"},
+				{Start: 1 * s, End: 2 * s, Text: "Bonjour.", Language: "fr"},
+			},
+			[]whisper.Segment{
+				{Start: 0, End: 1*s - defaultMinGap, Text: "Hello there.", SpeakerTurnNext: true, Language: "en"},
+				{Start: 1 * s, End: 2 * s, Text: "Bonjour.", Language: "fr", Num: 1},
+			},
+		},
+	}
+	for _, test := range tests {
+		result := Reflow(test.segments, ReflowOptions{})
+		if len(result) != len(test.expected) {
+			t.Errorf("%s: expected %d cues, got %d", test.name, len(test.expected), len(result))
+			continue
+		}
+		for i, cue := range result {
+			expected := test.expected[i]
+			if cue.Num != expected.Num || cue.Start != expected.Start || cue.End != expected.End || cue.Text != expected.Text ||
+				cue.Language != expected.Language || cue.SpeakerTurnNext != expected.SpeakerTurnNext {
+				t.Errorf("%s: cue %d is %+v, expected %+v", test.name, i, cue, expected)
+			}
+		}
+	}
+}
+
+func TestReflowLines(t *testing.T) {
+	words := strings.Fields(strings.Repeat("the quick brown fox jumps over the lazy dog ", 20))
+	segments := []whisper.Segment{{Start: 0, End: 60 * time.Second, Text: strings.Join(words, " ")}}
+	result := Reflow(segments, ReflowOptions{})
+	if len(result) < 2 {
+		t.Fatalf("expected the text to be split, got %d cues", len(result))
+	}
+
+	var text []string
+	for i, cue := range result {
+		lines := strings.Split(cue.Text, "\n")
+		if len(lines) > defaultMaxLines {
+			t.Errorf("cue %d has %d lines", i, len(lines))
+		}
+		for _, line := range lines {
+			if utf8.RuneCountInString(line) > defaultMaxLineLength {
+				t.Errorf("cue %d has line %q longer than %d characters", i, line, defaultMaxLineLength)
+			}
+		}
+		if cue.End-cue.Start > defaultMaxDuration {
+			t.Errorf("cue %d lasts %v", i, cue.End-cue.Start)
+		}
+		if i > 0 && cue.Start-result[i-1].End < defaultMinGap {
+			t.Errorf("cue %d starts %v after the previous cue", i, cue.Start-result[i-1].End)
+		}
+		text = append(text, strings.Fields(cue.Text)...)
+	}
+	if strings.Join(text, " ") != strings.Join(words, " ") {
+		t.Errorf("text changed by reflow")
+	}
+}
+
+func TestReflowFrameRate(t *testing.T) {
+	segments := []whisper.Segment{{Start: 10 * time.Millisecond, End: 2010 * time.Millisecond, Text: "Hello there."}}
+	result := Reflow(segments, ReflowOptions{FrameRate: 25})
+	if len(result) != 1 {
+		t.Fatalf("expected 1 cue, got %d", len(result))
+	} else if result[0].Start != 0 || result[0].End != 2*time.Second {
+		t.Errorf("expected cue from 0s to 2s, got %v to %v", result[0].Start, result[0].End)
+	}
+}
@@ -0,0 +1,376 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SCCEncoder writes Scenarist (.scc) captions, which carry CEA-608 byte
+// pairs for the first caption channel at 29.97 frames per second. The mode
+// and timecode must be set before the first call to Encode.
+type SCCEncoder struct {
+	w         io.Writer
+	header    bool
+	mode      SCCMode
+	dropFrame bool
+	next      int // First frame not yet written
+	clear     int // Frame at which to erase the displayed caption, or -1
+}
+
+// SCCMode is how captions are displayed
+type SCCMode int
+
+// sccChar is a character from the special or extended character sets,
+// which is sent as a byte pair. Extended characters replace the preceding
+// standard character, which is the fallback for older decoders.
+type sccChar struct {
+	code     uint16
+	fallback byte
+}
+
+// Make sure SCCEncoder adheres to the interface
+var _ Encoder = (*SCCEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	SCC_POP_ON    SCCMode = iota // Each caption is loaded off screen and shown at once
+	SCC_ROLL_UP_2                // Lines roll up from the bottom, with two rows shown
+	SCC_ROLL_UP_3                // Lines roll up from the bottom, with three rows shown
+	SCC_ROLL_UP_4                // Lines roll up from the bottom, with four rows shown
+)
+
+const (
+	sccColumns  = 32 // Characters on each row
+	sccMaxRows  = 4  // Rows in each pop-on caption
+	sccFrameNum = 30000
+	sccFrameDen = 1001
+)
+
+// Control codes for the first channel
+const (
+	sccRCL = 0x1420 // Resume caption loading, for pop-on captions
+	sccEDM = 0x142C // Erase displayed memory
+	sccCR  = 0x142D // Carriage return, which rolls up the rows
+	sccENM = 0x142E // Erase non-displayed memory
+	sccEOC = 0x142F // End of caption, which swaps displayed and non-displayed memory
+	sccRU2 = 0x1425 // Roll-up captions with two rows
+	sccTO1 = 0x1721 // Tab offset of one column
+)
+
+var (
+	// First byte and second byte of the preamble address code for the first
+	// column of each row, from row 1 to 15
+	sccRows = [15][2]byte{
+		{0x11, 0x40}, {0x11, 0x60}, {0x12, 0x40}, {0x12, 0x60}, {0x15, 0x40},
+		{0x15, 0x60}, {0x16, 0x40}, {0x16, 0x60}, {0x17, 0x40}, {0x17, 0x60},
+		{0x10, 0x40}, {0x13, 0x40}, {0x13, 0x60}, {0x14, 0x40}, {0x14, 0x60},
+	}
+
+	// Characters in the standard set which differ from ASCII
+	sccStandard = map[rune]byte{
+		'á': 0x2A, 'é': 0x5C, 'í': 0x5E, 'ó': 0x5F, 'ú': 0x60,
+		'ç': 0x7B, '÷': 0x7C, 'Ñ': 0x7D, 'ñ': 0x7E, '█': 0x7F,
+		'’': '\'', '`': '\'', '–': '-', '…': '.',
+	}
+
+	// Special and extended characters
+	sccExtended = map[rune]sccChar{
+		'®': {0x1130, 0}, '°': {0x1131, 0}, '½': {0x1132, 0}, '¿': {0x1133, 0},
+		'™': {0x1134, 0}, '¢': {0x1135, 0}, '£': {0x1136, 0}, '♪': {0x1137, 0},
+		'à': {0x1138, 0}, 'è': {0x113A, 0}, 'â': {0x113B, 0}, 'ê': {0x113C, 0},
+		'î': {0x113D, 0}, 'ô': {0x113E, 0}, 'û': {0x113F, 0},
+
+		'Á': {0x1220, 'A'}, 'É': {0x1221, 'E'}, 'Ó': {0x1222, 'O'}, 'Ú': {0x1223, 'U'},
+		'Ü': {0x1224, 'U'}, 'ü': {0x1225, 'u'}, '‘': {0x1226, '\''}, '¡': {0x1227, '!'},
+		'*': {0x1228, '.'}, '—': {0x122A, '-'}, '©': {0x122B, 'c'}, '℠': {0x122C, ' '},
+		'•': {0x122D, '.'}, '“': {0x122E, '"'}, '”': {0x122F, '"'}, 'À': {0x1230, 'A'},
+		'Â': {0x1231, 'A'}, 'Ç': {0x1232, 'C'}, 'È': {0x1233, 'E'}, 'Ê': {0x1234, 'E'},
+		'Ë': {0x1235, 'E'}, 'ë': {0x1236, 'e'}, 'Î': {0x1237, 'I'}, 'Ï': {0x1238, 'I'},
+		'ï': {0x1239, 'i'}, 'Ô': {0x123A, 'O'}, 'Ù': {0x123B, 'U'}, 'ù': {0x123C, 'u'},
+		'Û': {0x123D, 'U'}, '«': {0x123E, '"'}, '»': {0x123F, '"'},
+
+		'Ã': {0x1320, 'A'}, 'ã': {0x1321, 'a'}, 'Í': {0x1322, 'I'}, 'Ì': {0x1323, 'I'},
+		'ì': {0x1324, 'i'}, 'Ò': {0x1325, 'O'}, 'ò': {0x1326, 'o'}, 'Õ': {0x1327, 'O'},
+		'õ': {0x1328, 'o'}, '{': {0x1329, '['}, '}': {0x132A, ']'}, '\\': {0x132B, '/'},
+		'^': {0x132C, ' '}, '_': {0x132D, '-'}, '|': {0x132E, '!'}, '~': {0x132F, '-'},
+		'Ä': {0x1330, 'A'}, 'ä': {0x1331, 'a'}, 'Ö': {0x1332, 'O'}, 'ö': {0x1333, 'o'},
+		'ß': {0x1334, 's'}, '¥': {0x1335, 'Y'}, '¤': {0x1336, ' '}, '│': {0x1337, '!'},
+		'Å': {0x1338, 'A'}, 'å': {0x1339, 'a'}, 'Ø': {0x133A, 'O'}, 'ø': {0x133B, 'o'},
+		'┌': {0x133C, '+'}, '┐': {0x133D, '+'}, '└': {0x133E, '+'}, '┘': {0x133F, '+'},
+	}
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewSCCEncoder returns an encoder which writes pop-on SCC captions with
+// drop-frame timecode to w
+func NewSCCEncoder(w io.Writer) *SCCEncoder {
+	return &SCCEncoder{w: w, mode: SCC_POP_ON, dropFrame: true, clear: -1}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set pop-on or roll-up captions
+func (e *SCCEncoder) SetMode(mode SCCMode) {
+	e.mode = mode
+}
+
+// Set SMPTE drop-frame timecode, which keeps timecode in step with clock
+// time, or non-drop-frame timecode
+func (e *SCCEncoder) SetDropFrame(v bool) {
+	e.dropFrame = v
+}
+
+// Encode writes the header on the first call, and each segment with text
+// as captions. Text is wrapped to 32 columns, and characters outside the
+// CEA-608 character set are left out. Pop-on captions with more than four
+// rows, and roll-up captions with more than one row, are split over the
+// time of the segment.
+func (e *SCCEncoder) Encode(segments []whisper.Segment) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		var rows []string
+		for _, line := range cueLines(segment.Text) {
+			rows = append(rows, wrapText(sccText(line), sccColumns)...)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		// Split rows into captions over the time of the segment
+		size := sccMaxRows
+		if e.mode != SCC_POP_ON {
+			size = 1
+		}
+		start, end := cueTimes(segment)
+		n := (len(rows) + size - 1) / size
+		for i := 0; i < n; i++ {
+			from, to := start+(end-start)*time.Duration(i)/time.Duration(n), start+(end-start)*time.Duration(i+1)/time.Duration(n)
+			if err := e.writeCaption(rows[i*size:minInt(len(rows), (i+1)*size)], toFrames(from), toFrames(to)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the header if nothing has been encoded, and erases the last
+// caption
+func (e *SCCEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	if e.clear < 0 {
+		return nil
+	}
+	clear := e.clear
+	e.clear = -1
+	return e.writeCodes(maxInt(clear, e.next), []uint16{sccEDM, sccEDM})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *SCCEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	_, err := io.WriteString(e.w, "Scenarist_SCC V1.0\n\n")
+	return err
+}
+
+// writeCaption writes the codes for rows of text shown from the start frame
+// until the end frame, or until the next caption replaces them
+func (e *SCCEncoder) writeCaption(rows []string, start, end int) error {
+	var codes []uint16
+	var lead int // Codes sent before the caption is shown
+	switch e.mode {
+	case SCC_POP_ON:
+		codes = append(codes, sccRCL, sccRCL, sccENM, sccENM)
+		for i, row := range rows {
+			codes = append(codes, sccPosition(15-len(rows)+1+i, (sccColumns-len([]rune(row)))/2)...)
+			codes = append(codes, sccChars(row)...)
+		}
+		lead = len(codes)
+		codes = append(codes, sccEOC, sccEOC)
+	default:
+		ru := uint16(sccRU2 + int(e.mode-SCC_ROLL_UP_2))
+		codes = append(codes, ru, ru, sccCR, sccCR)
+		codes = append(codes, sccPosition(15, 0)...)
+		codes = append(codes, sccChars(rows[0])...)
+	}
+
+	// Start loading early enough for the caption to show at the start
+	// frame, but not before the previous codes have been sent
+	load := maxInt(start-lead, e.next)
+
+	// Erase the previous caption when it ends before this one is shown,
+	// within the codes for this caption if they have already started
+	if e.clear >= 0 {
+		clear := maxInt(e.clear, e.next)
+		switch {
+		case clear < load:
+			if err := e.writeCodes(clear, []uint16{sccEDM, sccEDM}); err != nil {
+				return err
+			}
+		case clear < load+lead:
+			i := clear - load
+			codes = append(codes[:i], append([]uint16{sccEDM, sccEDM}, codes[i:]...)...)
+		}
+		e.clear = -1
+	}
+	if err := e.writeCodes(load, codes); err != nil {
+		return err
+	}
+	e.clear = maxInt(end, e.next)
+
+	// Return success
+	return nil
+}
+
+// writeCodes writes a line of byte pairs sent from a frame, one pair in
+// each frame
+func (e *SCCEncoder) writeCodes(frame int, codes []uint16) error {
+	words := make([]string, len(codes))
+	for i, code := range codes {
+		words[i] = fmt.Sprintf("%02x%02x", withParity(byte(code>>8)), withParity(byte(code)))
+	}
+	e.next = frame + len(codes)
+	_, err := fmt.Fprintf(e.w, "%s\t%s\n\n", sccTimecode(frame, e.dropFrame), strings.Join(words, " "))
+	return err
+}
+
+// sccText returns text with only characters which can be sent, with other
+// spacing replaced by spaces
+func sccText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == ' ' || r == '\t':
+			b.WriteRune(' ')
+		case sccStandard[r] != 0:
+			b.WriteRune(r)
+		case sccExtended[r].code != 0:
+			b.WriteRune(r)
+		case r > ' ' && r < 0x7F:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sccChars returns the byte pairs for a row of text. Special and extended
+// characters are sent twice, like control codes, as decoders ignore the
+// repeat.
+func sccChars(text string) []uint16 {
+	var codes []uint16
+	var pending byte
+	add := func(c byte) {
+		if pending == 0 {
+			pending = c
+		} else {
+			codes, pending = append(codes, uint16(pending)<<8|uint16(c)), 0
+		}
+	}
+	flush := func() {
+		if pending != 0 {
+			codes, pending = append(codes, uint16(pending)<<8), 0
+		}
+	}
+	for _, r := range text {
+		if c, exists := sccStandard[r]; exists {
+			add(c)
+		} else if c, exists := sccExtended[r]; exists {
+			if c.fallback != 0 {
+				add(c.fallback)
+			}
+			flush()
+			codes = append(codes, c.code, c.code)
+		} else {
+			add(byte(r))
+		}
+	}
+	flush()
+	return codes
+}
+
+// sccPosition returns the codes which move the cursor to a row from 1 to
+// 15 and a column from 0 to 31, sent twice
+func sccPosition(row, column int) []uint16 {
+	indent := column / 4 * 4
+	pac := uint16(sccRows[row-1][0])<<8 | uint16(sccRows[row-1][1]+0x10+byte(indent/2))
+	codes := []uint16{pac, pac}
+	if tab := column - indent; tab > 0 {
+		codes = append(codes, uint16(sccTO1+tab-1), uint16(sccTO1+tab-1))
+	}
+	return codes
+}
+
+// withParity returns a byte with the top bit set to give odd parity
+func withParity(b byte) byte {
+	b &= 0x7F
+	n := 0
+	for v := b; v != 0; v >>= 1 {
+		n += int(v & 1)
+	}
+	if n%2 == 0 {
+		b |= 0x80
+	}
+	return b
+}
+
+// toFrames returns the number of frames at 29.97 frames per second
+func toFrames(t time.Duration) int {
+	if t < 0 {
+		return 0
+	}
+	return int(math.Round(t.Seconds() * sccFrameNum / sccFrameDen))
+}
+
+// sccTimecode returns a frame number as hh:mm:ss:ff, or as hh:mm:ss;ff
+// with drop-frame timecode, which skips frame numbers 0 and 1 at the start
+// of each minute except every tenth minute
+func sccTimecode(frame int, dropFrame bool) string {
+	sep := ':'
+	if dropFrame {
+		tens, rest := frame/17982, frame%17982
+		frame += 18 * tens
+		if rest > 1 {
+			frame += 2 * ((rest - 2) / 1798)
+		}
+		sep = ';'
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%c%02d", frame/108000, frame/1800%60, frame/30%60, sep, frame%30)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package subtitle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSCCTimecode(t *testing.T) {
+	tests := []struct {
+		frame     int
+		dropFrame bool
+		expected  string
+	}{
+		{0, false, "00:00:00:00"},
+		{29, false, "00:00:00:29"},
+		{1800, false, "00:01:00:00"},
+		{107999, false, "00:59:59:29"},
+		{108000, false, "01:00:00:00"},
+		{0, true, "00:00:00;00"},
+		{1799, true, "00:00:59;29"},
+		{1800, true, "00:01:00;02"},
+		{3597, true, "00:01:59;29"},
+		{3598, true, "00:02:00;02"},
+		{16183, true, "00:08:59;29"},
+		{16184, true, "00:09:00;02"},
+		{17981, true, "00:09:59;29"},
+		{17982, true, "00:10:00;00"},
+		{17983, true, "00:10:00;01"},
+		{19781, true, "00:10:59;29"},
+		{19782, true, "00:11:00;02"},
+		{35963, true, "00:19:59;29"},
+		{35964, true, "00:20:00;00"},
+		{107891, true, "00:59:59;29"},
+		{107892, true, "01:00:00;00"},
+	}
+	for _, test := range tests {
+		if v := sccTimecode(test.frame, test.dropFrame); v != test.expected {
+			t.Errorf("sccTimecode(%d, %v) = %q, expected %q", test.frame, test.dropFrame, v, test.expected)
+		}
+	}
+}
+
+func TestSCCFrames(t *testing.T) {
+	tests := []struct {
+		t        time.Duration
+		expected int
+	}{
+		{-time.Second, 0},
+		{0, 0},
+		{time.Second, 30},
+		{time.Minute, 1798},
+		{10 * time.Minute, 17982},
+		{time.Hour, 107892},
+	}
+	for _, test := range tests {
+		if v := toFrames(test.t); v != test.expected {
+			t.Errorf("toFrames(%v) = %d, expected %d", test.t, v, test.expected)
+		}
+	}
+}
+
+func TestSCCParity(t *testing.T) {
+	tests := []struct {
+		b, expected byte
+	}{
+		{0x00, 0x80},
+		{0x01, 0x01},
+		{0x03, 0x83},
+		{0x14, 0x94},
+		{0x20, 0x20},
+		{0x2C, 0x2C},
+		{0x41, 0xC1},
+		{0x7F, 0x7F},
+		{0x94, 0x94},
+		{0xFF, 0x7F},
+	}
+	for _, test := range tests {
+		if v := withParity(test.b); v != test.expected {
+			t.Errorf("withParity(0x%02X) = 0x%02X, expected 0x%02X", test.b, v, test.expected)
+		}
+	}
+}
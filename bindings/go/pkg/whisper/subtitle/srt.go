@@ -0,0 +1,81 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SRTEncoder writes SubRip (.srt) subtitles
+type SRTEncoder struct {
+	w      io.Writer
+	n      int  // Number of cues written
+	escape bool // Replace markup in the text
+}
+
+// Make sure SRTEncoder adheres to the interface
+var _ Encoder = (*SRTEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	// SubRip has no escapes, so when escaping is set, angle brackets which
+	// players read as <i> or <font> tags are replaced with look-alikes, and
+	// a space breaks up {\an8} style tags
+	srtEscape = strings.NewReplacer("<", "\u2039", ">", "\u203A", "{\\", "{ \\")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewSRTEncoder returns an encoder which writes SubRip subtitles to w
+func NewSRTEncoder(w io.Writer) *SRTEncoder {
+	return &SRTEncoder{w: w}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set escaping, where angle brackets in the text are replaced with the
+// look-alikes ‹ and ›, and {\ is broken up with a space, so that
+// players don't read transcript text as tags. Off by default, as it
+// changes the text.
+func (e *SRTEncoder) SetEscape(v bool) {
+	e.escape = v
+}
+
+// Encode writes each segment with text as a numbered cue, with markup in
+// the text replaced when escaping is set
+func (e *SRTEncoder) Encode(segments []whisper.Segment) error {
+	for _, segment := range segments {
+		lines := cueLines(segment.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		if e.escape {
+			for i, line := range lines {
+				lines[i] = srtEscape.Replace(line)
+			}
+		}
+		e.n++
+		start, end := cueTimes(segment)
+		if _, err := fmt.Fprintf(e.w, "%d\n%s --> %s\n%s\n\n", e.n, formatTimestamp(start, ','), formatTimestamp(end, ','), strings.Join(lines, "\n")); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close does nothing, as SubRip has no trailer
+func (e *SRTEncoder) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package subtitle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		t        time.Duration
+		sep      byte
+		expected string
+	}{
+		{-time.Second, ',', "00:00:00,000"},
+		{0, '.', "00:00:00.000"},
+		{1234 * time.Millisecond, ',', "00:00:01,234"},
+		{time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, '.', "01:02:03.004"},
+		{100 * time.Hour, ',', "100:00:00,000"},
+	}
+	for _, test := range tests {
+		if v := formatTimestamp(test.t, test.sep); v != test.expected {
+			t.Errorf("formatTimestamp(%v) = %q, expected %q", test.t, v, test.expected)
+		}
+	}
+}
+
+func TestSRTEncoder(t *testing.T) {
+	segments := []whisper.Segment{
+		{Start: 0, End: 1500 * time.Millisecond, Text: " Hello <b>world</b>"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "  "},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "{\\an8}first\n\nsecond"},
+	}
+	tests := []struct {
+		escape   bool
+		expected string
+	}{
+		{false, "1\n00:00:00,000 --> 00:00:01,500\nHello <b>world</b>\n\n" +
+			"2\n00:00:03,000 --> 00:00:04,000\n{\\an8}first\nsecond\n\n"},
+		{true, "1\n00:00:00,000 --> 00:00:01,500\nHello \u2039b\u203Aworld\u2039/b\u203A\n\n" +
+			"2\n00:00:03,000 --> 00:00:04,000\n{ \\an8}first\nsecond\n\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewSRTEncoder(&buf)
+		e.SetEscape(test.escape)
+		if err := e.Encode(segments); err != nil {
+			t.Fatal(err)
+		} else if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("escape %v: unexpected output %q", test.escape, buf.String())
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package subtitle
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TTMLEncoder writes TTML (.ttml) subtitles which conform to both EBU-TT-D
+// and the IMSC1 text profile. The language of the document is the language
+// set, or else the detected language of the first segment, as EBU-TT-D
+// requires one. The language and line length must be set before the first
+// call to Encode.
+type TTMLEncoder struct {
+	w          io.Writer
+	n          int // Number of cues written
+	header     bool
+	closed     bool
+	lang       string
+	lineLength int
+	karaoke    bool
+}
+
+// Make sure TTMLEncoder adheres to the interface
+var _ Encoder = (*TTMLEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultTTMLLineLength = 37
+	ttmlUndetermined      = "und" // BCP 47 language of an empty document
+)
+
+var (
+	ErrNoLanguage = errors.New("no language for subtitles")
+)
+
+const ttmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:ebuttm="urn:ebu:tt:metadata" xmlns:ebutts="urn:ebu:tt:style" ttp:timeBase="media" ttp:cellResolution="50 30" xml:lang="%s">
+  <head>
+    <metadata>
+      <ebuttm:documentMetadata>
+        <ebuttm:conformsToStandard>urn:ebu:tt:distribution:2018-04</ebuttm:conformsToStandard>
+        <ebuttm:conformsToStandard>http://www.w3.org/ns/ttml/profile/imsc1/text</ebuttm:conformsToStandard>
+      </ebuttm:documentMetadata>
+    </metadata>
+    <styling>
+      <style xml:id="paragraph" tts:textAlign="center" tts:fontFamily="proportionalSansSerif" tts:fontSize="100%%" tts:lineHeight="125%%" ebutts:linePadding="0.5c"/>
+      <style xml:id="span" tts:color="#FFFFFF" tts:backgroundColor="#000000"/>
+      <style xml:id="highlight" tts:color="#FFFF00"/>
+    </styling>
+    <layout>
+      <region xml:id="bottom" tts:origin="10%% 10%%" tts:extent="80%% 80%%" tts:displayAlign="after"/>
+    </layout>
+  </head>
+  <body>
+    <div>
+`
+
+const ttmlFooter = `    </div>
+  </body>
+</tt>
+`
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTTMLEncoder returns an encoder which writes TTML subtitles to w, with
+// lines of up to 37 characters
+func NewTTMLEncoder(w io.Writer) *TTMLEncoder {
+	return &TTMLEncoder{w: w, lineLength: defaultTTMLLineLength}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the language of the text, such as "en"
+func (e *TTMLEncoder) SetLanguage(lang string) {
+	e.lang = strings.TrimSpace(lang)
+}
+
+// Set the maximum characters on each line, or zero to keep lines as they are
+func (e *TTMLEncoder) SetLineLength(n int) {
+	e.lineLength = n
+}
+
+// Set karaoke mode, where each word is highlighted from when it is spoken
+// using token timestamps. Each word is a span with nested spans for the
+// word before and after it is highlighted. Segments without token
+// timestamps are written as plain paragraphs.
+func (e *TTMLEncoder) SetKaraoke(v bool) {
+	e.karaoke = v
+}
+
+// Encode writes the header before the first paragraph, and each segment
+// with text as a paragraph. Lines are wrapped to the line length, and
+// characters which XML does not allow are left out. ErrNoLanguage is
+// returned when a segment has text but no language is set or detected.
+func (e *TTMLEncoder) Encode(segments []whisper.Segment) error {
+	for _, segment := range segments {
+		var lines []string
+		for _, line := range cueLines(ttmlText(segment.Text)) {
+			if e.lineLength > 0 {
+				lines = append(lines, wrapText(line, e.lineLength)...)
+			} else {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if err := e.writeHeader(segment.Language); err != nil {
+			return err
+		}
+		e.n++
+		if err := e.writeParagraph(segment, lines); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the end of the document, and the header if nothing has been
+// encoded. An empty document has the language set, or else is marked as
+// undetermined.
+func (e *TTMLEncoder) Close() error {
+	if err := e.writeHeader(ttmlUndetermined); err != nil {
+		return err
+	}
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	_, err := io.WriteString(e.w, ttmlFooter)
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// writeHeader writes the header with the language set, or else the
+// detected language
+func (e *TTMLEncoder) writeHeader(detected string) error {
+	if e.header {
+		return nil
+	}
+	lang := e.lang
+	if lang == "" {
+		lang = strings.TrimSpace(detected)
+	}
+	if lang == "" {
+		return ErrNoLanguage
+	}
+	e.header = true
+	_, err := fmt.Fprintf(e.w, ttmlHeader, ttmlEscape(lang))
+	return err
+}
+
+func (e *TTMLEncoder) writeParagraph(segment whisper.Segment, lines []string) error {
+	start, end := cueTimes(segment)
+	spans := make([]string, len(lines))
+	if words, timed := timedWords(segment); e.karaoke && timed {
+		for i, words := range lineWords(words, lines) {
+			spans[i] = `<span style="span">` + ttmlKaraoke(words, start, end) + `</span>`
+		}
+	} else {
+		for i, line := range lines {
+			spans[i] = `<span style="span">` + ttmlEscape(line) + `</span>`
+		}
+	}
+	_, err := fmt.Fprintf(e.w, "      <p xml:id=\"sub%d\" region=\"bottom\" style=\"paragraph\" begin=\"%s\" end=\"%s\">%s</p>\n",
+		e.n, formatTimestamp(start, '.'), formatTimestamp(end, '.'), strings.Join(spans, "<br/>"))
+	return err
+}
+
+// ttmlKaraoke returns a span for each word, which is highlighted from the
+// start of the word until the end of the paragraph. Times of nested spans
+// are relative to the start of the paragraph.
+func ttmlKaraoke(words []whisper.Word, start, end time.Duration) string {
+	parts := make([]string, len(words))
+	for i, word := range words {
+		text := ttmlEscape(ttmlText(word.Text))
+		if offset := word.Start - start; word.Start > start && word.Start < end {
+			at := formatTimestamp(offset, '.')
+			parts[i] = `<span><span end="` + at + `">` + text + `</span><span begin="` + at + `" style="highlight">` + text + `</span></span>`
+		} else if word.Start <= start {
+			parts[i] = `<span style="highlight">` + text + `</span>`
+		} else {
+			parts[i] = `<span>` + text + `</span>`
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ttmlText returns text without characters which XML does not allow, or
+// which are control characters
+func ttmlText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r), r == unicode.ReplacementChar, r == 0xFFFE, r == 0xFFFF, r >= 0xD800 && r < 0xE000:
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// ttmlEscape returns text escaped for XML content or attributes
+func ttmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
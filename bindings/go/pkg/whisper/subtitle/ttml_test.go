@@ -0,0 +1,51 @@
+package subtitle
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestTTMLLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		segments []whisper.Segment
+		expected string
+		err      error
+	}{
+		{"empty", "", nil, `xml:lang="und"`, nil},
+		{"empty with language", "de", nil, `xml:lang="de"`, nil},
+		{"silent segment", "", []whisper.Segment{{End: time.Second, Text: " "}}, `xml:lang="und"`, nil},
+		{"detected", "", []whisper.Segment{{End: time.Second, Text: "Bonjour", Language: "fr"}}, `xml:lang="fr"`, nil},
+		{"set", "en", []whisper.Segment{{End: time.Second, Text: "Bonjour", Language: "fr"}}, `xml:lang="en"`, nil},
+		{"missing", "", []whisper.Segment{{End: time.Second, Text: "Bonjour"}}, "", ErrNoLanguage},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewTTMLEncoder(&buf)
+		e.SetLanguage(test.lang)
+		err := e.Encode(test.segments)
+		if err == nil {
+			err = e.Close()
+		}
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !strings.Contains(buf.String(), test.expected) {
+			t.Errorf("%s: expected %s in %q", test.name, test.expected, buf.String())
+		} else if !strings.HasSuffix(buf.String(), ttmlFooter) {
+			t.Errorf("%s: document is not closed", test.name)
+		}
+	}
+}
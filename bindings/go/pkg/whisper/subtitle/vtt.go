@@ -0,0 +1,170 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	// Packages
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// VTTEncoder writes WebVTT (.vtt) subtitles. The style, notes and cue
+// settings must be set before the first call to Encode.
+type VTTEncoder struct {
+	w        io.Writer
+	n        int // Number of cues written
+	header   bool
+	style    string
+	notes    []string
+	settings string
+	karaoke  bool
+}
+
+// Make sure VTTEncoder adheres to the interface
+var _ Encoder = (*VTTEncoder)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	vttEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewVTTEncoder returns an encoder which writes WebVTT subtitles to w
+func NewVTTEncoder(w io.Writer) *VTTEncoder {
+	return &VTTEncoder{w: w}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set CSS written in a STYLE block, such as "::cue { color: yellow; }"
+func (e *VTTEncoder) SetStyle(css string) {
+	e.style = css
+}
+
+// Add a comment written in a NOTE block after the header
+func (e *VTTEncoder) AddNote(text string) {
+	e.notes = append(e.notes, text)
+}
+
+// Set cue settings written after the timestamps of every cue, such as
+// "line:85% align:center"
+func (e *VTTEncoder) SetCueSettings(settings string) {
+	e.settings = strings.Join(strings.Fields(settings), " ")
+}
+
+// Set karaoke mode, where each word after the first is preceded by a
+// timestamp tag such as <00:00:01.200>, using token timestamps. Segments
+// without token timestamps are written as plain cues. Players
+// style words before and after the tag with the :past and :future
+// pseudo-classes.
+func (e *VTTEncoder) SetKaraoke(v bool) {
+	e.karaoke = v
+}
+
+// Encode writes the header on the first call, and each segment with text
+// as a numbered cue
+func (e *VTTEncoder) Encode(segments []whisper.Segment) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		lines := cueLines(segment.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		e.n++
+		if err := e.writeCue(segment, lines); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close writes the header if nothing has been encoded, so that the file is
+// valid even without cues
+func (e *VTTEncoder) Close() error {
+	return e.writeHeader()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *VTTEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	if style := blockText(e.style); style != "" {
+		fmt.Fprintf(&b, "STYLE\n%s\n\n", style)
+	}
+	for _, note := range e.notes {
+		if note := blockText(note); note != "" {
+			fmt.Fprintf(&b, "NOTE\n%s\n\n", note)
+		}
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *VTTEncoder) writeCue(segment whisper.Segment, lines []string) error {
+	start, end := cueTimes(segment)
+	timing := formatTimestamp(start, '.') + " --> " + formatTimestamp(end, '.')
+	if e.settings != "" {
+		timing += " " + e.settings
+	}
+	if words, timed := timedWords(segment); e.karaoke && timed {
+		lines = vttKaraokeLines(words, lines, start, end)
+	} else {
+		for i, line := range lines {
+			lines[i] = vttEscape.Replace(line)
+		}
+	}
+	_, err := fmt.Fprintf(e.w, "%d\n%s\n%s\n\n", e.n, timing, strings.Join(lines, "\n"))
+	return err
+}
+
+// vttKaraokeLines returns the lines of a cue with a timestamp tag before
+// each word which starts within the cue, after the start of the cue
+func vttKaraokeLines(words []whisper.Word, lines []string, start, end time.Duration) []string {
+	result := make([]string, len(lines))
+	last := start
+	for i, words := range lineWords(words, lines) {
+		parts := make([]string, len(words))
+		for j, word := range words {
+			parts[j] = vttEscape.Replace(word.Text)
+			if word.Start > last && word.Start < end {
+				parts[j] = "<" + formatTimestamp(word.Start, '.') + ">" + parts[j]
+				last = word.Start
+			}
+		}
+		result[i] = strings.Join(parts, " ")
+	}
+	return result
+}
+
+// blockText returns text for a STYLE or NOTE block, which may not contain
+// blank lines or the "-->" string
+func blockText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.ReplaceAll(line, "-->", "->"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,89 @@
+package whisper
+
+import (
+	"math"
+	"strings"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// negInf is the logit of a token which is never decoded
+var negInf = float32(math.Inf(-1))
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set token ids which are never decoded. Set to nil to remove all ids.
+func (context *context) SetSuppressTokens(ids []int) {
+	context.suppressIds = nil
+	for _, id := range ids {
+		if id >= 0 {
+			context.suppressIds = append(context.suppressIds, whisper.Token(id))
+		}
+	}
+}
+
+// Set text which is never decoded, such as words or symbols, within a
+// sentence or at the start of the text. Text which is more than one token
+// is suppressed by never decoding its last token after the others. Set to
+// nil to remove all text.
+func (context *context) SetSuppressText(text []string) error {
+	if context.model.ctx == nil {
+		return ErrInternalAppError
+	}
+	var result [][]whisper.Token
+	for _, text := range text {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		variants := []string{text}
+		if !strings.HasPrefix(text, " ") {
+			variants = append(variants, " "+text)
+		}
+		for _, variant := range variants {
+			tokens, err := tokenize(context.model.ctx, variant)
+			if err != nil {
+				return err
+			} else if len(tokens) > 0 {
+				result = append(result, tokens)
+			}
+		}
+	}
+	context.suppressText = result
+
+	// Return success
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// applySuppress sets the logits of suppressed tokens to negative infinity
+func (context *context) applySuppress(tokens []whisper.TokenData, logits []float32) {
+	for _, id := range context.suppressIds {
+		if int(id) < len(logits) {
+			logits[id] = negInf
+		}
+	}
+	if len(context.suppressText) == 0 {
+		return
+	}
+
+	eot := context.model.ctx.Whisper_token_eot()
+	text := make([]whisper.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Id() < eot {
+			text = append(text, token.Id())
+		}
+	}
+	for _, suppress := range context.suppressText {
+		last := len(suppress) - 1
+		if int(suppress[last]) < len(logits) && hasSuffix(text, suppress[:last]) {
+			logits[suppress[last]] = negInf
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package whisper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Transcript is a document with the result of speech recognition. Its JSON
+// has the same fields as the output of "main -oj", so either can be read
+// by the same tools, and adds a version and the tokens of each segment.
+type Transcript struct {
+	TranscriptHeader
+	Transcription []TranscriptSegment `json:"transcription"`
+}
+
+// TranscriptHeader is everything in a transcript except the segments, which
+// is the first line of a JSONL transcript
+type TranscriptHeader struct {
+	Version    int              `json:"version,omitempty"`
+	SystemInfo string           `json:"systeminfo"`
+	Model      TranscriptModel  `json:"model"`
+	Params     TranscriptParams `json:"params"`
+	Result     TranscriptResult `json:"result"`
+}
+
+// TranscriptModel describes the model used for recognition. The end of
+// text token is not in the output of "main -oj".
+type TranscriptModel struct {
+	Type         string           `json:"type"`
+	Multilingual bool             `json:"multilingual"`
+	Vocab        int              `json:"vocab"`
+	Audio        TranscriptLayers `json:"audio"`
+	Text         TranscriptLayers `json:"text"`
+	Mels         int              `json:"mels"`
+	Ftype        int              `json:"ftype"`
+	EOT          int              `json:"eot,omitempty"`
+}
+
+// TranscriptLayers describes the encoder or decoder of the model
+type TranscriptLayers struct {
+	Ctx   int `json:"ctx"`
+	State int `json:"state"`
+	Head  int `json:"head"`
+	Layer int `json:"layer"`
+}
+
+// TranscriptParams are the parameters used for recognition
+type TranscriptParams struct {
+	Model     string `json:"model"`
+	Language  string `json:"language"`
+	Translate bool   `json:"translate"`
+}
+
+// TranscriptResult is the detected language
+type TranscriptResult struct {
+	Language string `json:"language"`
+}
+
+// TranscriptSegment is a segment of a transcript. The speaker is set by
+// callers which know it, such as from stereo channels. The speaker turn is
+// set for every segment when tinydiarize is enabled, and otherwise only
+// when it is true.
+type TranscriptSegment struct {
+	Timestamps      TranscriptTimestamps `json:"timestamps"`
+	Offsets         TranscriptOffsets    `json:"offsets"`
+	Text            string               `json:"text"`
+	Speaker         string               `json:"speaker,omitempty"`
+	SpeakerTurnNext *bool                `json:"speaker_turn_next,omitempty"`
+	Tokens          []TranscriptToken    `json:"tokens,omitempty"`
+}
+
+// TranscriptToken is a token of a segment
+type TranscriptToken struct {
+	Text       string               `json:"text"`
+	Timestamps TranscriptTimestamps `json:"timestamps"`
+	Offsets    TranscriptOffsets    `json:"offsets"`
+	Id         int                  `json:"id"`
+	P          float32              `json:"p"`
+}
+
+// TranscriptTimestamps are times as "hh:mm:ss,mmm"
+type TranscriptTimestamps struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TranscriptOffsets are times in milliseconds
+type TranscriptOffsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// TranscriptEncoder writes a transcript as JSONL, with the header on the
+// first line and a segment on each following line
+type TranscriptEncoder struct {
+	w *json.Encoder
+}
+
+// TranscriptDecoder reads a transcript written as JSONL
+type TranscriptDecoder struct {
+	r      *json.Decoder
+	header TranscriptHeader
+	n      int // Number of segments read
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// TranscriptVersion is the version of transcripts which are written.
+	// Transcripts without a version are from "main -oj".
+	TranscriptVersion = 1
+
+	// The end of text token of English-only models, for transcripts which
+	// don't include it. It is one more for multilingual models, and tokens
+	// from it onwards are not text.
+	transcriptTokenEOT = 50256
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTranscriptEncoder returns an encoder which writes JSONL to w, and
+// writes the header
+func NewTranscriptEncoder(w io.Writer, header TranscriptHeader) (*TranscriptEncoder, error) {
+	encoder := &TranscriptEncoder{json.NewEncoder(w)}
+	encoder.w.SetEscapeHTML(false)
+	if err := encoder.w.Encode(header); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return encoder, nil
+}
+
+// NewTranscriptDecoder returns a decoder which reads JSONL from r, and
+// reads the header
+func NewTranscriptDecoder(r io.Reader) (*TranscriptDecoder, error) {
+	decoder := &TranscriptDecoder{r: json.NewDecoder(r)}
+	if err := decoder.r.Decode(&decoder.header); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	} else if decoder.header.Version > TranscriptVersion {
+		return nil, ErrUnsupportedTranscript
+	}
+
+	// Return success
+	return decoder, nil
+}
+
+// NewTranscript returns a transcript of segments processed with a context,
+// with the model, parameters and detected language
+func NewTranscript(c Context, segments []Segment) (*Transcript, error) {
+	context, ok := c.(*context)
+	if !ok || context.model.ctx == nil {
+		return nil, ErrInternalAppError
+	}
+	ctx := context.model.ctx
+	transcript := new(Transcript)
+	transcript.Version = TranscriptVersion
+	transcript.SystemInfo = whisper.Whisper_print_system_info()
+	transcript.Model = TranscriptModel{
+		Type:         ctx.Whisper_model_type_readable(),
+		Multilingual: ctx.Whisper_is_multilingual() != 0,
+		Vocab:        ctx.Whisper_model_n_vocab(),
+		Audio: TranscriptLayers{
+			Ctx:   ctx.Whisper_model_n_audio_ctx(),
+			State: ctx.Whisper_model_n_audio_state(),
+			Head:  ctx.Whisper_model_n_audio_head(),
+			Layer: ctx.Whisper_model_n_audio_layer(),
+		},
+		Text: TranscriptLayers{
+			Ctx:   ctx.Whisper_model_n_text_ctx(),
+			State: ctx.Whisper_model_n_text_state(),
+			Head:  ctx.Whisper_model_n_text_head(),
+			Layer: ctx.Whisper_model_n_text_layer(),
+		},
+		Mels:  ctx.Whisper_model_n_mels(),
+		Ftype: ctx.Whisper_model_ftype(),
+		EOT:   int(ctx.Whisper_token_eot()),
+	}
+	transcript.Params = TranscriptParams{
+		Model:     context.model.path,
+		Language:  context.Language(),
+		Translate: context.params.Translate(),
+	}
+	transcript.Result.Language = context.Language()
+	for _, segment := range segments {
+		if segment.Language != "" {
+			transcript.Result.Language = segment.Language
+			break
+		}
+	}
+	transcript.Transcription = make([]TranscriptSegment, len(segments))
+	for i, segment := range segments {
+		transcript.Transcription[i] = NewTranscriptSegment(segment)
+		if context.params.Tinydiarize() {
+			turn := segment.SpeakerTurnNext
+			transcript.Transcription[i].SpeakerTurnNext = &turn
+		}
+	}
+
+	// Return success
+	return transcript, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// NewTranscriptSegment returns a segment for a transcript
+func NewTranscriptSegment(segment Segment) TranscriptSegment {
+	result := TranscriptSegment{
+		Timestamps: transcriptTimestamps(segment.Start, segment.End),
+		Offsets:    transcriptOffsets(segment.Start, segment.End),
+		Text:       segment.Text,
+	}
+	if segment.SpeakerTurnNext {
+		result.SpeakerTurnNext = &segment.SpeakerTurnNext
+	}
+	for _, token := range segment.Tokens {
+		result.Tokens = append(result.Tokens, TranscriptToken{
+			Text:       token.Text,
+			Timestamps: transcriptTimestamps(token.Start, token.End),
+			Offsets:    transcriptOffsets(token.Start, token.End),
+			Id:         token.Id,
+			P:          token.P,
+		})
+	}
+	return result
+}
+
+// Segment returns the segment with the given number, in the language
+// detected in the header. Tokens are special when their id is at or after
+// the end of text token of the model in the header, or of the standard
+// vocabulary when the header doesn't include it.
+func (s TranscriptSegment) Segment(n int, header TranscriptHeader) Segment {
+	result := Segment{
+		Num:             n,
+		Start:           time.Duration(s.Offsets.From) * time.Millisecond,
+		End:             time.Duration(s.Offsets.To) * time.Millisecond,
+		Text:            s.Text,
+		SpeakerTurnNext: s.SpeakerTurnNext != nil && *s.SpeakerTurnNext,
+		Language:        header.Result.Language,
+	}
+	eot := header.Model.EOT
+	if eot <= 0 {
+		if eot = transcriptTokenEOT; header.Model.Multilingual {
+			eot++
+		}
+	}
+	for _, token := range s.Tokens {
+		result.Tokens = append(result.Tokens, Token{
+			Id:      token.Id,
+			Text:    token.Text,
+			P:       token.P,
+			Start:   time.Duration(token.Offsets.From) * time.Millisecond,
+			End:     time.Duration(token.Offsets.To) * time.Millisecond,
+			Special: token.Id >= eot,
+		})
+	}
+	return result
+}
+
+// Segments returns the segments of the transcript, in the detected
+// language
+func (t *Transcript) Segments() []Segment {
+	result := make([]Segment, len(t.Transcription))
+	for i, segment := range t.Transcription {
+		result[i] = segment.Segment(i, t.TranscriptHeader)
+	}
+	return result
+}
+
+// EncodeTranscript writes a transcript as JSON, indented with tabs like the
+// output of "main -oj"
+func EncodeTranscript(w io.Writer, transcript *Transcript) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(transcript)
+}
+
+// DecodeTranscript reads a transcript written as JSON
+func DecodeTranscript(r io.Reader) (*Transcript, error) {
+	transcript := new(Transcript)
+	if err := json.NewDecoder(r).Decode(transcript); err != nil {
+		return nil, err
+	} else if transcript.Version > TranscriptVersion {
+		return nil, ErrUnsupportedTranscript
+	}
+
+	// Return success
+	return transcript, nil
+}
+
+// Encode writes each segment on a line
+func (e *TranscriptEncoder) Encode(segments []Segment) error {
+	for _, segment := range segments {
+		if err := e.w.Encode(NewTranscriptSegment(segment)); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// Close does nothing, as JSONL has no trailer
+func (e *TranscriptEncoder) Close() error {
+	return nil
+}
+
+// Header returns the header of the transcript
+func (d *TranscriptDecoder) Header() TranscriptHeader {
+	return d.header
+}
+
+// Decode returns the next segment, or io.EOF at the end of the transcript
+func (d *TranscriptDecoder) Decode() (Segment, error) {
+	var segment TranscriptSegment
+	if err := d.r.Decode(&segment); err != nil {
+		return Segment{}, err
+	}
+	d.n++
+	return segment.Segment(d.n-1, d.header), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func transcriptTimestamps(from, to time.Duration) TranscriptTimestamps {
+	return TranscriptTimestamps{From: transcriptTimestamp(from), To: transcriptTimestamp(to)}
+}
+
+func transcriptOffsets(from, to time.Duration) TranscriptOffsets {
+	return TranscriptOffsets{From: from.Milliseconds(), To: to.Milliseconds()}
+}
+
+// transcriptTimestamp returns a time as hh:mm:ss,mmm
+func transcriptTimestamp(t time.Duration) string {
+	if t < 0 {
+		t = 0
+	}
+	ms := t.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
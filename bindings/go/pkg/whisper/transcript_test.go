@@ -0,0 +1,86 @@
+package whisper
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscriptSpecial(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    TranscriptModel
+		id       int
+		expected bool
+	}{
+		{"english text", TranscriptModel{}, 50255, false},
+		{"english eot", TranscriptModel{}, 50256, true},
+		{"multilingual text", TranscriptModel{Multilingual: true}, 50256, false},
+		{"multilingual eot", TranscriptModel{Multilingual: true}, 50257, true},
+		{"model text", TranscriptModel{Multilingual: true, EOT: 51865}, 51864, false},
+		{"model eot", TranscriptModel{Multilingual: true, EOT: 51865}, 51865, true},
+	}
+	for _, test := range tests {
+		header := TranscriptHeader{Model: test.model}
+		segment := TranscriptSegment{Text: "x", Tokens: []TranscriptToken{{Id: test.id, Text: "x"}}}
+		if v := segment.Segment(0, header).Tokens[0].Special; v != test.expected {
+			t.Errorf("%s: token %d special is %v, expected %v", test.name, test.id, v, test.expected)
+		}
+	}
+}
+
+func TestTranscriptJSONL(t *testing.T) {
+	header := TranscriptHeader{
+		Version: TranscriptVersion,
+		Model:   TranscriptModel{Multilingual: true, EOT: 100},
+		Result:  TranscriptResult{Language: "de"},
+	}
+	segments := []Segment{
+		{Start: 0, End: time.Second, Text: " Hallo", Tokens: []Token{
+			{Id: 100, Text: "[_BEG_]", Special: true},
+			{Id: 10, Text: " Hallo", P: 0.5, Start: 0, End: time.Second},
+		}},
+		{Start: time.Second, End: 2 * time.Second, Text: " Welt", SpeakerTurnNext: true},
+	}
+
+	var buf bytes.Buffer
+	encoder, err := NewTranscriptEncoder(&buf, header)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := encoder.Encode(segments); err != nil {
+		t.Fatal(err)
+	} else if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Fatalf("expected 3 lines, got %d", n)
+	}
+
+	decoder, err := NewTranscriptDecoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if decoder.Header().Model.EOT != 100 {
+		t.Errorf("expected end of text token 100, got %d", decoder.Header().Model.EOT)
+	}
+	for i, expected := range segments {
+		segment, err := decoder.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if segment.Num != i || segment.Start != expected.Start || segment.End != expected.End || segment.Text != expected.Text ||
+			segment.SpeakerTurnNext != expected.SpeakerTurnNext || segment.Language != "de" || len(segment.Tokens) != len(expected.Tokens) {
+			t.Errorf("segment %d is %+v, expected %+v", i, segment, expected)
+			continue
+		}
+		for j, token := range segment.Tokens {
+			if token != expected.Tokens[j] {
+				t.Errorf("segment %d token %d is %+v, expected %+v", i, j, token, expected.Tokens[j])
+			}
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package whisper
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	// Bindings
+	whisper "github.com/brave-experiments/whisper.cpp/bindings/go"
+	audio "github.com/brave-experiments/whisper.cpp/bindings/go/pkg/audio"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// span maps a run of samples in the joined speech back to the original audio
+type span struct {
+	from, to, n int // Start in the joined speech, start in the original, length
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	gatePadding = 500 * time.Millisecond // Audio processed after each run of speech
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// processSpeech runs the model only on the regions of speech found by the
+// voice activity detector, joined together, and maps timestamps back onto
+// the original audio
+func (context *context) processSpeech(s State, data []float32) ([]Segment, error) {
+	// Apply the offset and duration to the original audio rather than to
+	// the joined speech
+	joined := *context
+	data, first := trimAudio(&joined.params, data)
+
+	var speech []float32
+	var spans []span
+	for _, region := range context.vad.Detect(data) {
+		from, to := toSamples(region.Start), toSamples(region.End)
+		if to > len(data) {
+			to = len(data)
+		}
+		if to <= from {
+			continue
+		}
+		spans = append(spans, span{len(speech), first + from, to - from})
+		speech = append(speech, data[from:to]...)
+	}
+	if len(speech) == 0 {
+		return []Segment{}, nil
+	}
+
+	segments, err := joined.process(s, speech)
+	if err != nil {
+		return nil, err
+	}
+	for i, segment := range segments {
+		segment.Start = mapTime(spans, segment.Start, false)
+		segment.End = mapTime(spans, segment.End, true)
+		tokens := make([]Token, len(segment.Tokens))
+		for j, token := range segment.Tokens {
+			token.Start = mapTime(spans, token.Start, false)
+			token.End = mapTime(spans, token.End, true)
+			tokens[j] = token
+		}
+		segment.Tokens = tokens
+		segments[i] = segment
+	}
+
+	// Return success
+	return segments, nil
+}
+
+// processGated runs the model on each run of speech, skipping silences of
+// at least one encoder window, so the mel spectrogram is only computed for
+// the audio which is encoded. Within a run, whisper.cpp aborts processing
+// when the encoder begin callback returns false, which stops encoding the
+// silence after the last speech.
+func (context *context) processGated(s State, data []float32) ([]Segment, error) {
+	st := s.(*state).st
+	window := toSamples(time.Duration(whisper.ChunkSize) * time.Second)
+
+	// Apply the offset and duration here, as each run is processed separately
+	params := context.fullParams()
+	data, first := trimAudio(&params, data)
+	regions := context.gate.Detect(data)
+
+	var result []Segment
+	pos, end := 0, len(data)
+	for {
+		base := nextSpeech(regions, pos)
+		if base >= end {
+			break
+		}
+		stop := speechEnd(regions, base, window) + toSamples(gatePadding)
+		if shortest := base + toSamples(time.Second); stop < shortest {
+			stop = shortest
+		}
+		if stop > end {
+			stop = end
+		}
+		pos = stop
+		if stop-base < toSamples(time.Second) {
+			break
+		}
+
+		// The state doesn't expose its position, so it is bounded below by
+		// the end of the last segment, and above by a whole window for each
+		// run of the encoder. Encoding stops only when there is no speech
+		// from the lower bound to a window past the upper bound, so speech
+		// is never skipped.
+		low, high, segments, calls := 0, 0, 0, 0
+		gate := func() bool {
+			if calls > 0 {
+				high += window
+			}
+			calls++
+			if n := st.Whisper_full_n_segments(); n > segments {
+				if t1 := int(st.Whisper_full_get_segment_t1(n-1)) * SampleRate / 100; t1 > low {
+					low = t1
+				}
+				segments = n
+			}
+			if high < low {
+				high = low
+			}
+			return nextSpeech(regions, base+low) < base+high+window
+		}
+		if err := context.model.ctx.Whisper_full_with_state_callbacks(st, params, data[base:stop], whisper.FullCallbacks{
+			EncoderBegin: gate,
+			LogitsFilter: context.logitsFilter(),
+		}); err != nil {
+			return nil, err
+		}
+
+		// Collect segments relative to the start of the audio
+		offset := toDuration(first + base)
+		for _, segment := range context.toSegments(st) {
+			segment = shiftSegment(segment, offset)
+			segment.Num = len(result)
+			result = append(result, segment)
+		}
+	}
+
+	// Return success
+	return result, nil
+}
+
+// speechEnd returns the sample position where the speech at or after pos
+// ends, joining regions separated by less than gap samples
+func speechEnd(regions []audio.Region, pos, gap int) int {
+	result := pos
+	for _, region := range regions {
+		start, end := toSamples(region.Start), toSamples(region.End)
+		if end <= result {
+			continue
+		} else if start-result >= gap && result > pos {
+			break
+		}
+		result = end
+	}
+	return result
+}
+
+// nextSpeech returns the sample position of the first speech at or after
+// pos, or the maximum int if there is none
+func nextSpeech(regions []audio.Region, pos int) int {
+	for _, region := range regions {
+		if end := toSamples(region.End); end <= pos {
+			continue
+		} else if start := toSamples(region.Start); start > pos {
+			return start
+		} else {
+			return pos
+		}
+	}
+	return math.MaxInt
+}
+
+// mapTime maps a time in the joined speech onto the original audio. A time
+// on the boundary between two spans maps to the end of the first when end is
+// true, or the start of the second otherwise.
+func mapTime(spans []span, t time.Duration, end bool) time.Duration {
+	n := toSamples(t)
+	i := sort.Search(len(spans), func(i int) bool {
+		if end {
+			return spans[i].from >= n
+		}
+		return spans[i].from > n
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	offset := n - spans[i].from
+	if offset > spans[i].n {
+		offset = spans[i].n
+	}
+	return toDuration(spans[i].to + offset)
+}
@@ -0,0 +1,92 @@
+package whisper
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Words returns the words of the segment, assembled from its text tokens. A
+// token which starts with a space begins a new word, and other tokens are
+// joined onto the word before, so that characters split across tokens are
+// rejoined. Scripts written without spaces have one word per token.
+func (s Segment) Words() []Word {
+	var result []Word
+	var text []byte
+	var logp float64
+	var n int
+	var word Word
+
+	flush := func() {
+		if n > 0 {
+			word.Text = strings.TrimSpace(strings.ToValidUTF8(string(text), string(utf8.RuneError)))
+			word.Confidence = float32(math.Exp(logp / float64(n)))
+			if word.Text != "" {
+				result = append(result, word)
+			}
+		}
+		text, logp, n = text[:0], 0, 0
+		word.Tokens = nil
+	}
+
+	for _, token := range s.Tokens {
+		if token.IsSpecial() || token.Text == "" {
+			continue
+		}
+		if n > 0 && startsWord(text, token.Text) {
+			flush()
+		}
+		if n == 0 {
+			word.Start = token.Start
+		}
+		text = append(text, token.Text...)
+		word.Tokens = append(word.Tokens, token)
+		logp += math.Log(math.Max(float64(token.P), 1e-10))
+		word.End = token.End
+		n++
+	}
+	flush()
+
+	return result
+}
+
+// IsSpecial returns true for tokens which are not text, such as timestamps
+// and the start and end of transcription. Tokens which are not marked as
+// special are also special when their text is in the form whisper.cpp uses
+// for special tokens, such as "[_BEG_]" or "<|endoftext|>".
+func (t Token) IsSpecial() bool {
+	return t.Special || isSpecialText(t.Text)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// startsWord returns true if token begins a new word after the text so far
+func startsWord(text []byte, token string) bool {
+	switch {
+	case token[0] == ' ':
+		return true
+	case !utf8.Valid(text):
+		// Part way through a character split across tokens
+		return false
+	default:
+		r, _ := utf8.DecodeRuneInString(token)
+		return r != utf8.RuneError && isUnspaced(r)
+	}
+}
+
+// isSpecialText returns true for the text of special tokens
+func isSpecialText(text string) bool {
+	return len(text) > 3 && (strings.HasPrefix(text, "[_") && strings.HasSuffix(text, "]") ||
+		strings.HasPrefix(text, "<|") && strings.HasSuffix(text, "|>"))
+}
+
+// isUnspaced returns true for characters of scripts written without spaces
+// between words
+func isUnspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
@@ -0,0 +1,89 @@
+package whisper
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []string
+		special  []bool
+		expected []string
+	}{
+		{"empty", nil, nil, nil},
+		{"spaces", []string{" Hello", " wor", "ld", "."}, nil, []string{"Hello", "world."}},
+		{"leading", []string{"Hello", " there"}, nil, []string{"Hello", "there"}},
+		{"marked special", []string{" Hello", " there"}, []bool{true, false}, []string{"there"}},
+		{"special text", []string{"[_BEG_]", " Hello", "[_TT_150]", "<|endoftext|>"}, nil, []string{"Hello"}},
+		{"annotation", []string{" [", "Music", "]", " Hi"}, nil, []string{"[Music]", "Hi"}},
+		{"blank", []string{" ", " Hi", " "}, nil, []string{"Hi"}},
+		{"unspaced", []string{"你", "好"}, nil, []string{"你", "好"}},
+		{"split character", []string{"\xe4\xbd", "\xa0", "\xe5\xa5\xbd"}, nil, []string{"你", "好"}},
+	}
+	for _, test := range tests {
+		var segment Segment
+		for i, text := range test.tokens {
+			token := Token{Id: i, Text: text, P: 1, Start: time.Duration(i) * time.Second, End: time.Duration(i+1) * time.Second}
+			if test.special != nil {
+				token.Special = test.special[i]
+			}
+			segment.Tokens = append(segment.Tokens, token)
+		}
+		var result []string
+		for _, word := range segment.Words() {
+			result = append(result, word.Text)
+		}
+		if strings.Join(result, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%s: words are %q, expected %q", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestWordTimes(t *testing.T) {
+	segment := Segment{Tokens: []Token{
+		{Text: "[_BEG_]", Start: 0, End: 0},
+		{Text: " wor", P: 0.25, Start: time.Second, End: 2 * time.Second},
+		{Text: "ld", P: 1, Start: 2 * time.Second, End: 3 * time.Second},
+	}}
+	words := segment.Words()
+	if len(words) != 1 {
+		t.Fatalf("expected 1 word, got %d", len(words))
+	}
+	word := words[0]
+	if word.Start != time.Second || word.End != 3*time.Second {
+		t.Errorf("word is from %v to %v, expected 1s to 3s", word.Start, word.End)
+	}
+	if math.Abs(float64(word.Confidence)-0.5) > 1e-6 {
+		t.Errorf("confidence is %v, expected 0.5", word.Confidence)
+	}
+	if len(word.Tokens) != 2 {
+		t.Errorf("expected 2 tokens, got %d", len(word.Tokens))
+	}
+}
+
+func TestIsSpecial(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected bool
+	}{
+		{Token{Text: " Hello"}, false},
+		{Token{Text: " Hello", Special: true}, true},
+		{Token{Text: "[_BEG_]"}, true},
+		{Token{Text: "[_TT_1500]"}, true},
+		{Token{Text: "<|endoftext|>"}, true},
+		{Token{Text: "<|en|>"}, true},
+		{Token{Text: "[Music]"}, false},
+		{Token{Text: " ["}, false},
+		{Token{Text: "<|"}, false},
+		{Token{Text: "[_]"}, false},
+	}
+	for _, test := range tests {
+		if v := test.token.IsSpecial(); v != test.expected {
+			t.Errorf("IsSpecial(%+v) = %v, expected %v", test.token, v, test.expected)
+		}
+	}
+}
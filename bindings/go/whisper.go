@@ -2,6 +2,7 @@ package whisper
 
 import (
 	"errors"
+	"sync"
 	"unsafe"
 )
 
@@ -13,6 +14,31 @@ import (
 #cgo darwin LDFLAGS: -framework Accelerate
 #include <whisper.h>
 #include <stdlib.h>
+
+extern bool callEncoderBegin(void* state);
+extern void callLogitsFilter(struct whisper_context* ctx, void* state, whisper_token_data* tokens, int n_tokens, float* logits);
+
+// Encoder begin callback
+// Called before the encoder starts, with the state as the key for the
+// Go callback. If it returns false, the computation is aborted
+static bool whisper_encoder_begin_cb(struct whisper_context* ctx, struct whisper_state* state, void* user_data) {
+    return callEncoderBegin((void*)(state));
+}
+
+// Logits filter callback
+// Called by each decoder to filter obtained logits, with the state as the
+// key for the Go callback
+static void whisper_logits_filter_cb(struct whisper_context* ctx, struct whisper_state* state, const whisper_token_data* tokens, int n_tokens, float* logits, void* user_data) {
+    callLogitsFilter(ctx, (void*)(state), (whisper_token_data*)(tokens), n_tokens, logits);
+}
+
+static void whisper_full_params_set_encoder_begin_cb(struct whisper_full_params* params) {
+    params->encoder_begin_callback = whisper_encoder_begin_cb;
+}
+
+static void whisper_full_params_set_logits_filter_cb(struct whisper_full_params* params) {
+    params->logits_filter_callback = whisper_logits_filter_cb;
+}
 */
 import "C"
 
@@ -28,6 +54,18 @@ type (
 	State            C.struct_whisper_state
 )
 
+// Callbacks for Whisper_full_with_state_callbacks. Callbacks which are nil
+// are not called.
+type FullCallbacks struct {
+	// Called before each run of the encoder. The computation is aborted if
+	// it returns false.
+	EncoderBegin func() bool
+
+	// Called with the tokens decoded so far and the logits for the next
+	// token, which it can modify before sampling
+	LogitsFilter func(tokens []TokenData, logits []float32)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // GLOBALS
 
@@ -45,6 +83,13 @@ const (
 	ChunkSize  = C.WHISPER_CHUNK_SIZE
 )
 
+var (
+	// Callbacks registered for each state while it is processing
+	cbLock         sync.Mutex
+	cbEncoderBegin = make(map[unsafe.Pointer]func() bool)
+	cbLogitsFilter = make(map[unsafe.Pointer]func([]TokenData, []float32))
+)
+
 var (
 	ErrTokenizerFailed  = errors.New("whisper_tokenize failed")
 	ErrAutoDetectFailed = errors.New("whisper_lang_auto_detect failed")
@@ -183,6 +228,56 @@ func (ctx *Context) Whisper_is_multilingual() int {
 	return int(C.whisper_is_multilingual((*C.struct_whisper_context)(ctx)))
 }
 
+// Model hyperparameters
+func (ctx *Context) Whisper_model_n_vocab() int {
+	return int(C.whisper_model_n_vocab((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_audio_ctx() int {
+	return int(C.whisper_model_n_audio_ctx((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_audio_state() int {
+	return int(C.whisper_model_n_audio_state((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_audio_head() int {
+	return int(C.whisper_model_n_audio_head((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_audio_layer() int {
+	return int(C.whisper_model_n_audio_layer((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_text_ctx() int {
+	return int(C.whisper_model_n_text_ctx((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_text_state() int {
+	return int(C.whisper_model_n_text_state((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_text_head() int {
+	return int(C.whisper_model_n_text_head((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_text_layer() int {
+	return int(C.whisper_model_n_text_layer((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_n_mels() int {
+	return int(C.whisper_model_n_mels((*C.struct_whisper_context)(ctx)))
+}
+
+func (ctx *Context) Whisper_model_ftype() int {
+	return int(C.whisper_model_ftype((*C.struct_whisper_context)(ctx)))
+}
+
+// Model type, such as "base" or "large"
+func (ctx *Context) Whisper_model_type_readable() string {
+	return C.GoString(C.whisper_model_type_readable((*C.struct_whisper_context)(ctx)))
+}
+
 // Token Id -> String. Uses the vocabulary in the provided context
 func (ctx *Context) Whisper_token_to_str(token Token) string {
 	return C.GoString(C.whisper_token_to_str((*C.struct_whisper_context)(ctx), C.whisper_token(token)))
@@ -261,7 +356,28 @@ func (ctx *Context) Whisper_full_with_state(
 	params Params,
 	samples []float32,
 ) error {
-	if C.whisper_full_with_state((*C.struct_whisper_context)(ctx),(*C.struct_whisper_state)(state), (C.struct_whisper_full_params)(params), (*C.float)(&samples[0]), C.int(len(samples))) == 0 {
+	return ctx.Whisper_full_with_state_callbacks(state, params, samples, FullCallbacks{})
+}
+
+// Run the entire model, as Whisper_full_with_state, calling the callbacks
+// which are not nil
+func (ctx *Context) Whisper_full_with_state_callbacks(
+	state *State,
+	params Params,
+	samples []float32,
+	callbacks FullCallbacks,
+) error {
+	if callbacks.EncoderBegin != nil {
+		C.whisper_full_params_set_encoder_begin_cb((*C.struct_whisper_full_params)(&params))
+		registerCallback(cbEncoderBegin, unsafe.Pointer(state), callbacks.EncoderBegin)
+		defer unregisterCallback(cbEncoderBegin, unsafe.Pointer(state))
+	}
+	if callbacks.LogitsFilter != nil {
+		C.whisper_full_params_set_logits_filter_cb((*C.struct_whisper_full_params)(&params))
+		registerCallback(cbLogitsFilter, unsafe.Pointer(state), callbacks.LogitsFilter)
+		defer unregisterCallback(cbLogitsFilter, unsafe.Pointer(state))
+	}
+	if C.whisper_full_with_state((*C.struct_whisper_context)(ctx), (*C.struct_whisper_state)(state), (C.struct_whisper_full_params)(params), (*C.float)(&samples[0]), C.int(len(samples))) == 0 {
 		return nil
 	} else {
 		return ErrConversionFailed
@@ -329,6 +445,47 @@ func (state *State) Whisper_full_get_token_p(segment int, token int) float32 {
 
 ///////////////////////////////////////////////////////////////////////////////
 // CALLBACKS
+
+//export callEncoderBegin
+func callEncoderBegin(state unsafe.Pointer) C.bool {
+	cbLock.Lock()
+	fn, exists := cbEncoderBegin[state]
+	cbLock.Unlock()
+	if exists {
+		return toBool(fn())
+	}
+	return toBool(true)
+}
+
+//export callLogitsFilter
+func callLogitsFilter(ctx *C.struct_whisper_context, state unsafe.Pointer, tokens *C.whisper_token_data, n_tokens C.int, logits *C.float) {
+	cbLock.Lock()
+	fn, exists := cbLogitsFilter[state]
+	cbLock.Unlock()
+	if exists {
+		var data []TokenData
+		if n_tokens > 0 {
+			data = unsafe.Slice((*TokenData)(unsafe.Pointer(tokens)), int(n_tokens))
+		}
+		fn(data, unsafe.Slice((*float32)(unsafe.Pointer(logits)), int(C.whisper_n_vocab(ctx))))
+	}
+}
+
+func registerCallback[T any](callbacks map[unsafe.Pointer]T, state unsafe.Pointer, fn T) {
+	cbLock.Lock()
+	defer cbLock.Unlock()
+	callbacks[state] = fn
+}
+
+func unregisterCallback[T any](callbacks map[unsafe.Pointer]T, state unsafe.Pointer) {
+	cbLock.Lock()
+	defer cbLock.Unlock()
+	delete(callbacks, state)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TOKEN DATA
+
 func (t TokenData) T0() int64 {
 	return int64(t.t0)
 }